@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// pendingApproval is a call from Claude's message that the ask policy would otherwise confirm on its own
+type pendingApproval struct {
+	id     string
+	name   string
+	target string
+}
+
+// pendingApprovals lists the mutating calls in a message, among those that will run, whose tools have the ask policy
+func (a *Agent) pendingApprovals(content []anthropic.ContentBlockUnion) []pendingApproval {
+	var pending []pendingApproval
+	calls := 0
+	for _, block := range content {
+		if block.Type != "tool_use" {
+			continue
+		}
+		calls++
+		if config.MaxToolCalls > 0 && calls > config.MaxToolCalls {
+			break
+		}
+		if toolPolicy(block.Name) != PolicyAsk {
+			continue
+		}
+		for _, tool := range a.tools {
+			if tool.Name == block.Name && tool.Mutates {
+				pending = append(pending, pendingApproval{id: block.ID, name: block.Name, target: toolTarget(block.Input)})
+			}
+		}
+	}
+
+	return pending
+}
+
+// approveBatch shows the pending calls together and asks once whether they may run, returning the decision for
+// each by tool use ID
+func (a *Agent) approveBatch(pending []pendingApproval) map[string]bool {
+	var summary strings.Builder
+	summary.WriteString("Claude wants to make these changes:")
+	for i, call := range pending {
+		fmt.Fprintf(&summary, "\n  %d. %s", i+1, call.name)
+		if call.target != "" {
+			fmt.Fprintf(&summary, " %s", call.target)
+		}
+	}
+	// The summary is shown once, not again when an answer has to be asked for again
+	for details := summary.String(); ; details = "" {
+		a.questionPrompt(details, "Allow [a]ll, [n]one, or the numbers to allow, e.g. 1 3")
+		answer, ok := a.readAnswer()
+		if !ok {
+			a.unansweredPrompt()
+			answer = "n"
+		}
+
+		approved, valid := parseBatchAnswer(answer, len(pending))
+		if !valid {
+			continue
+		}
+
+		decisions := make(map[string]bool, len(pending))
+		for i, call := range pending {
+			decisions[call.id] = approved[i]
+		}
+		return decisions
+	}
+}
+
+// parseBatchAnswer turns an answer to approveBatch into whether each of n calls is approved: a or all approves
+// every call, n, none or nothing approves none, and numbers separated by spaces or commas approve just those
+func parseBatchAnswer(answer string, n int) ([]bool, bool) {
+	approved := make([]bool, n)
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "a", "all", "y", "yes":
+		for i := range approved {
+			approved[i] = true
+		}
+		return approved, true
+	case "", "n", "none", "no":
+		return approved, true
+	}
+
+	for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' }) {
+		number, err := strconv.Atoi(field)
+		if err != nil || number < 1 || number > n {
+			return nil, false
+		}
+		approved[number-1] = true
+	}
+
+	return approved, true
+}
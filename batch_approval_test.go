@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseBatchAnswer(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   []bool
+		valid  bool
+	}{
+		{"a", []bool{true, true, true}, true},
+		{" All ", []bool{true, true, true}, true},
+		{"n", []bool{false, false, false}, true},
+		{"", []bool{false, false, false}, true},
+		{"1 3", []bool{true, false, true}, true},
+		{"2,3", []bool{false, true, true}, true},
+		{"4", nil, false},
+		{"0", nil, false},
+		{"first", nil, false},
+	}
+
+	for _, test := range tests {
+		got, valid := parseBatchAnswer(test.answer, 3)
+		if valid != test.valid || !slices.Equal(got, test.want) {
+			t.Errorf("parseBatchAnswer(%q) = %v, %t, want %v, %t", test.answer, got, valid, test.want, test.valid)
+		}
+	}
+}
+
+// batchMessage is Claude's reply creating three files, with a read in between that needs no approval
+func batchMessage() string {
+	return messageJSON(
+		textBlock("I'll add the three files."),
+		toolUseBlock("tool_1", "create_file", `{"path":"one.txt","content":"1"}`),
+		toolUseBlock("tool_2", "read_file", `{"path":"notes.txt"}`),
+		toolUseBlock("tool_3", "create_file", `{"path":"two.txt","content":"2"}`),
+		toolUseBlock("tool_4", "create_file", `{"path":"three.txt","content":"3"}`),
+	)
+}
+
+func TestBatchApprove(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []string
+		created []string
+	}{
+		{"approve all", []string{"a"}, []string{"one.txt", "two.txt", "three.txt"}},
+		{"deny all", []string{"n"}, nil},
+		{"per item", []string{"1 3"}, []string{"one.txt", "three.txt"}},
+		{"asks again after an invalid answer", []string{"5", "2"}, []string{"two.txt"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			config.BatchApprove = true
+			config.ToolPolicies = map[string]string{"create_file": PolicyAsk}
+			writeFile(t, "notes.txt", "notes")
+
+			client := fakeAPI(t, func(request map[string]any) string {
+				if len(request["messages"].([]any)) == 1 {
+					return batchMessage()
+				}
+				return messageJSON(textBlock("Done"))
+			})
+			// No answers are scripted beyond the batch ones, so a second prompt would see the end of input
+			agent := newTestAgent(client, []ToolDefinition{CreateFileDefinition, ReadFileDefinition}, test.answers...)
+
+			output := captureStdout(t, func() {
+				if err := agent.RunOnce(context.Background(), "add the files"); err != nil {
+					t.Error(err)
+				}
+			})
+
+			if strings.Count(output, "Allow [a]ll, [n]one") != len(test.answers) {
+				t.Errorf("asked %d times, want %d:\n%s", strings.Count(output, "Allow [a]ll, [n]one"), len(test.answers), output)
+			}
+			if want := "Claude wants to make these changes:\n  1. create_file one.txt\n  2. create_file two.txt\n  3. create_file three.txt"; !strings.Contains(output, want) {
+				t.Errorf("output lacks the summary %q:\n%s", want, output)
+			}
+			if strings.Contains(output, "Allow create_file?") {
+				t.Errorf("a call was confirmed on its own:\n%s", output)
+			}
+
+			for _, name := range []string{"one.txt", "two.txt", "three.txt"} {
+				_, err := os.Stat(name)
+				if created := err == nil; created != slices.Contains(test.created, name) {
+					t.Errorf("%s created: %t, want %t", name, created, !created)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchApproveSingleChange(t *testing.T) {
+	setupWorkspace(t)
+	config.BatchApprove = true
+	config.ToolPolicies = map[string]string{"create_file": PolicyAsk}
+
+	client := fakeAPI(t, toolThenText("create_file", `{"path":"one.txt","content":"1"}`, "Done", nil))
+	agent := newTestAgent(client, []ToolDefinition{CreateFileDefinition}, "y")
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "add a file"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if strings.Contains(output, "these changes") || !strings.Contains(output, "Allow create_file? [y/N]") {
+		t.Errorf("a single change wasn't confirmed as usual:\n%s", output)
+	}
+	if got := readFile(t, "one.txt"); got != "1" {
+		t.Errorf("one.txt has %q", got)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var ChangeDirectoryDefinition = ToolDefinition{
+	Name:        "change_directory",
+	Description: "Change the working directory for the rest of the session so later relative paths resolve against it. Paths are relative to the current working directory and can't leave the workspace. Use '..' to go up.",
+	InputSchema: ChangeDirectoryInputSchema,
+	Function:    ChangeDirectory,
+}
+
+type ChangeDirectoryInput struct {
+	Path string `json:"path" jsonschema_description:"The directory to change to, relative to the current working directory."`
+}
+
+var ChangeDirectoryInputSchema = GenerateSchema[ChangeDirectoryInput]()
+
+func ChangeDirectory(input json.RawMessage) (string, error) {
+	changeDirectoryInput := ChangeDirectoryInput{}
+	err := json.Unmarshal(input, &changeDirectoryInput)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := resolvePath(changeDirectoryInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory: %w", changeDirectoryInput.Path, ErrInvalidInput)
+	}
+
+	root, err := workspaceRoot()
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(root, resolved)
+	if err != nil {
+		return "", err
+	}
+
+	setWorkDir(relPath)
+	return fmt.Sprintf("Working directory is now %s", filepath.ToSlash(relPath)), nil
+}
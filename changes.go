@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Kinds of change recorded for the files touched during a turn
+const (
+	changeAdded    = "added"
+	changeModified = "changed"
+	changeDeleted  = "deleted"
+)
+
+// toolTarget returns the path input of a tool call, or an empty string when it has none
+func toolTarget(input json.RawMessage) string {
+	var target struct {
+		Path string `json:"path"`
+	}
+	if json.Unmarshal(input, &target) != nil {
+		return ""
+	}
+
+	return target.Path
+}
+
+// toolTargets returns the files a mutating tool call would change: those its Targets function lists, or else its
+// path input
+func toolTargets(toolDef ToolDefinition, input json.RawMessage) ([]string, error) {
+	if toolDef.Targets != nil {
+		return toolDef.Targets(input)
+	}
+	if target := toolTarget(input); target != "" {
+		return []string{target}, nil
+	}
+
+	return nil, nil
+}
+
+// pathExists reports whether a tool supplied path currently exists
+func pathExists(p string) bool {
+	resolved, err := resolvePath(p)
+	if err != nil {
+		return false
+	}
+	_, err = os.Lstat(resolved)
+
+	return err == nil
+}
+
+// recordChange notes how a successful mutating tool call changed p, folding it into earlier changes this turn so
+// a file added then edited is still reported as added and one added then deleted isn't reported at all
+func (a *Agent) recordChange(p string, existedBefore bool) {
+	existsAfter := pathExists(p)
+
+	var kind string
+	switch {
+	case existedBefore && existsAfter:
+		kind = changeModified
+	case existedBefore:
+		kind = changeDeleted
+	case existsAfter:
+		kind = changeAdded
+	default:
+		return
+	}
+
+	if a.changes == nil {
+		a.changes = map[string]string{}
+	}
+	switch previous := a.changes[p]; {
+	case previous == changeAdded && kind == changeDeleted:
+		delete(a.changes, p)
+	case previous == changeAdded:
+	case previous == changeDeleted && kind == changeAdded:
+		a.changes[p] = changeModified
+	default:
+		a.changes[p] = kind
+	}
+}
+
+// changesSummary lists the files touched this turn grouped by kind, e.g. "changed: main.go; added: util.go"
+func changesSummary(changes map[string]string) string {
+	var groups []string
+	for _, kind := range []string{changeModified, changeAdded, changeDeleted} {
+		var paths []string
+		for p, k := range changes {
+			if k == kind {
+				paths = append(paths, p)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		slices.Sort(paths)
+		groups = append(groups, fmt.Sprintf("%s: %s", kind, strings.Join(paths, ", ")))
+	}
+
+	return strings.Join(groups, "; ")
+}
+
+// Changes prompt summarising the files touched during the turn that just finished
+func (a *Agent) changesPrompt() {
+	summary := changesSummary(a.changes)
+	if summary == "" {
+		return
+	}
+	if a.jsonOutput {
+		a.emit(outputEvent{Type: "files_changed", Text: summary})
+		return
+	}
+	if config.Quiet {
+		return
+	}
+	fmt.Println(a.colorize(ANSI_DIM, summary))
+}
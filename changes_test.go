@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestChangesSummary(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "main.go", "package main\n")
+	writeFile(t, "old.go", "package main\n")
+	writeFile(t, "gone.go", "package main\n")
+	tools := []ToolDefinition{EditFileDefinition, CreateFileDefinition, DeleteFileDefinition}
+	agent := newTestAgent(nil, tools)
+
+	calls := []struct {
+		name  string
+		input string
+	}{
+		{"edit_file", `{"path":"main.go","old_str":"main","new_str":"app"}`},
+		{"create_file", `{"path":"util.go","content":"package app\n"}`},
+		{"edit_file", `{"path":"util.go","old_str":"app","new_str":"main"}`},
+		{"delete_file", `{"path":"old.go"}`},
+		{"create_file", `{"path":"temp.go","content":"package main\n"}`},
+		{"delete_file", `{"path":"temp.go"}`},
+		{"edit_file", `{"path":"missing.go","old_str":"a","new_str":"b"}`},
+	}
+	captureStdout(t, func() {
+		for _, call := range calls {
+			agent.runTool("tool_1", call.name, json.RawMessage(call.input))
+		}
+	})
+
+	if got, want := changesSummary(agent.changes), "changed: main.go; added: util.go; deleted: old.go"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestChangesRecordEveryTarget(t *testing.T) {
+	setupRenameModule(t)
+	writeFile(t, "notes.txt", "Greet the user\n")
+	agent := newTestAgent(nil, []ToolDefinition{RenameSymbolDefinition, ReplaceInFilesDefinition})
+
+	captureStdout(t, func() {
+		agent.runTool("tool_1", "rename_symbol", json.RawMessage(`{"file":"greet.go","line":3,"column":6,"new_name":"SayHello"}`))
+		agent.runTool("tool_2", "replace_in_files", json.RawMessage(`{"pattern":"the user","replacement":"everyone","glob":"*.txt"}`))
+	})
+
+	if got, want := changesSummary(agent.changes), "changed: greet.go, main.go, notes.txt, other.go"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTargetsErrorFailsClosed(t *testing.T) {
+	setupWorkspace(t)
+	config.ProtectedPaths = []string{"*.lock"}
+	tool := ToolDefinition{
+		Name:        "write_lock",
+		InputSchema: ReadFileInputSchema,
+		Function: func(json.RawMessage) (string, error) {
+			return "", os.WriteFile("deps.lock", []byte("changed"), 0644)
+		},
+		Targets: func(json.RawMessage) ([]string, error) {
+			return nil, errors.New("can't tell which files would change")
+		},
+		Mutates: true,
+	}
+	agent := newTestAgent(nil, []ToolDefinition{tool})
+
+	var result ToolResult
+	captureStdout(t, func() {
+		result = agent.runTool("tool_1", "write_lock", json.RawMessage(`{"path":"notes.txt"}`))
+	})
+
+	if result.Status != ToolError || result.Message != "can't tell which files would change" {
+		t.Errorf("got %s %q, want the Targets error", result.Status, result.Message)
+	}
+	if _, err := os.Stat("deps.lock"); err == nil {
+		t.Error("the tool ran although its targets couldn't be checked")
+	}
+}
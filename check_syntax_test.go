@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		summary string
+		want    syntaxResult
+	}{
+		{
+			name:    "valid go",
+			file:    "ok.go",
+			content: "package main\n\nfunc main() {}\n",
+			summary: "0 syntax errors in ok.go",
+			want:    syntaxResult{Language: "go", Supported: true, Errors: []syntaxError{}},
+		},
+		{
+			name:    "unsupported",
+			file:    "script.py",
+			content: "def f(:\n",
+			summary: "syntax checking is not supported for script.py",
+			want:    syntaxResult{Language: "py", Errors: []syntaxError{}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, test.file, test.content)
+
+			input, _ := json.Marshal(map[string]any{"path": test.file})
+			result, err := CheckSyntax(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Status != ToolSuccess || result.Message != test.summary {
+				t.Errorf("got %s %q, want success %q", result.Status, result.Message, test.summary)
+			}
+			if !reflect.DeepEqual(result.Data, test.want) {
+				t.Errorf("got %+v, want %+v", result.Data, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckSyntaxReportsPositions(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "bad.go", "package main\n\nfunc main() {\n\tx := \n}\n")
+
+	result, err := callTool(t, func(input json.RawMessage) (string, error) {
+		result, err := CheckSyntax(input)
+		content, _ := result.content()
+		return content, err
+	}, map[string]any{"path": "bad.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, data, _ := strings.Cut(result, "\n")
+	var checked syntaxResult
+	if err := json.Unmarshal([]byte(data), &checked); err != nil {
+		t.Fatal(err)
+	}
+	if summary != fmt.Sprintf("%d syntax errors in bad.go", len(checked.Errors)) || len(checked.Errors) == 0 {
+		t.Fatalf("got summary %q for %d errors", summary, len(checked.Errors))
+	}
+	if first := checked.Errors[0]; first != (syntaxError{Line: 5, Column: 1, Message: "expected operand, found '}'"}) {
+		t.Errorf("first error is %+v", first)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+var ChmodDefinition = ToolDefinition{
+	Name:        "chmod",
+	Description: "Change a file's permissions. Either set executable to make a script runnable, or not, or give an explicit octal mode such as '0644'. Returns the old and new permissions.",
+	InputSchema: ChmodInputSchema,
+	Function:    Chmod,
+	Mutates:     true,
+}
+
+type ChmodInput struct {
+	Path       string `json:"path" jsonschema_description:"The relative path of the file."`
+	Mode       string `json:"mode,omitempty" jsonschema_description:"The permissions as an octal string, e.g. '0755'. Give either this or executable."`
+	Executable *bool  `json:"executable,omitempty" jsonschema_description:"True to add execute permission for everyone who can read the file, false to remove all execute permission."`
+}
+
+var ChmodInputSchema = GenerateSchema[ChmodInput]()
+
+func Chmod(input json.RawMessage) (string, error) {
+	chmodInput := ChmodInput{}
+	err := json.Unmarshal(input, &chmodInput)
+	if err != nil {
+		return "", err
+	}
+
+	if (chmodInput.Mode == "") == (chmodInput.Executable == nil) {
+		return "", fmt.Errorf("give exactly one of mode or executable: %w", ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(chmodInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkRegularFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	old := info.Mode().Perm()
+
+	var mode os.FileMode
+	switch {
+	case chmodInput.Mode != "":
+		mode, err = parseFileMode(chmodInput.Mode)
+		if err != nil {
+			return "", err
+		}
+	case *chmodInput.Executable:
+		// Like chmod +x, but only for those who can read the file, as they are the only ones who could run it
+		mode = old | (old&0444)>>2
+	default:
+		mode = old &^ 0111
+	}
+
+	err = os.Chmod(resolved, mode)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Changed %s from %04o (%s) to %04o (%s)", chmodInput.Path, old, old, mode, mode), nil
+}
+
+// parseFileMode parses an octal permission string such as "755" or "0644"
+func parseFileMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q, expected octal permissions such as 0644: %w", value, ErrInvalidInput)
+	}
+
+	return os.FileMode(mode), nil
+}
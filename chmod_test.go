@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// fileMode returns the permission bits of a file in the workspace
+func fileMode(t *testing.T, name string) os.FileMode {
+	t.Helper()
+
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Mode().Perm()
+}
+
+func TestChmodExecutable(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "run.sh", "#!/bin/sh\necho hi\n")
+	if err := os.Chmod("run.sh", 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := callTool(t, Chmod, map[string]any{"path": "run.sh", "executable": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Changed run.sh from 0640 (-rw-r-----) to 0750 (-rwxr-x---)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if mode := fileMode(t, "run.sh"); mode != 0750 {
+		t.Errorf("mode is %04o, want execute for those who can read", mode)
+	}
+
+	if _, err := callTool(t, Chmod, map[string]any{"path": "run.sh", "executable": false}); err != nil {
+		t.Fatal(err)
+	}
+	if mode := fileMode(t, "run.sh"); mode != 0640 {
+		t.Errorf("mode is %04o, want execute removed", mode)
+	}
+}
+
+func TestChmodMode(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "config.yaml", "key: value\n")
+
+	for _, test := range []struct {
+		mode string
+		want os.FileMode
+	}{
+		{"0600", 0600},
+		{"755", 0755},
+	} {
+		if _, err := callTool(t, Chmod, map[string]any{"path": "config.yaml", "mode": test.mode}); err != nil {
+			t.Fatalf("mode %s: %v", test.mode, err)
+		}
+		if mode := fileMode(t, "config.yaml"); mode != test.want {
+			t.Errorf("mode %s: file has %04o, want %04o", test.mode, mode, test.want)
+		}
+	}
+}
+
+func TestChmodInvalid(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", "x")
+	if err := os.Mkdir("dir", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  error
+	}{
+		{"not octal", map[string]any{"path": "file.txt", "mode": "0789"}, ErrInvalidInput},
+		{"symbolic", map[string]any{"path": "file.txt", "mode": "u+x"}, ErrInvalidInput},
+		{"beyond permissions", map[string]any{"path": "file.txt", "mode": "4755"}, ErrInvalidInput},
+		{"neither", map[string]any{"path": "file.txt"}, ErrInvalidInput},
+		{"both", map[string]any{"path": "file.txt", "mode": "0644", "executable": true}, ErrInvalidInput},
+		{"outside the workspace", map[string]any{"path": "../file.txt", "executable": true}, ErrOutsideWorkspace},
+	}
+
+	for _, test := range tests {
+		if _, err := callTool(t, Chmod, test.input); !errors.Is(err, test.want) {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.want)
+		}
+	}
+	if _, err := callTool(t, Chmod, map[string]any{"path": "dir", "mode": "0700"}); err == nil {
+		t.Error("changed the mode of a directory")
+	}
+	if mode := fileMode(t, "dir"); mode != 0755 {
+		t.Errorf("directory mode is %04o", mode)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// commandToolTimeout backstops tools that run commands, which are themselves limited by --command-timeout
+const commandToolTimeout = 10 * time.Minute
+
+// runCommand executes a command in the session's working directory, returning its combined output.
+// A non-zero exit is reported through a *exec.ExitError alongside the output.
+func runCommand(name string, args ...string) (string, error) {
+	return runCommandStream(nil, name, args...)
+}
+
+// runCommandStream is runCommand that additionally copies the output to stream, when not nil, as it is produced
+func runCommandStream(stream io.Writer, name string, args ...string) (string, error) {
+	if !config.AllowCommands {
+		return "", ErrCommandsDisabled
+	}
+
+	dir, err := commandDir()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.CommandTimeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	var writer io.Writer = &output
+	if stream != nil {
+		writer = io.MultiWriter(&output, stream)
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	err = cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output.String(), fmt.Errorf("%s %s did not finish within %s: %w", name, strings.Join(args, " "), config.CommandTimeout, ErrTimeout)
+	}
+
+	return output.String(), err
+}
+
+// commandDir returns the directory commands run in, refusing it when --exec-dir limits commands to other subtrees
+func commandDir() (string, error) {
+	dir, err := resolvePath(".")
+	if err != nil {
+		return "", err
+	}
+	if !allowedExecDir(dir) {
+		return "", fmt.Errorf("commands may only run in %s, change_directory into one of them first: %w", strings.Join(config.ExecDirs, ", "), ErrExecDirDenied)
+	}
+
+	return dir, nil
+}
+
+// allowedExecDir reports whether dir, a resolved path, is within one of the --exec-dir subtrees, if any are given
+func allowedExecDir(dir string) bool {
+	if len(config.ExecDirs) == 0 {
+		return true
+	}
+
+	root, err := workspaceRoot()
+	if err != nil {
+		return false
+	}
+	for _, allowed := range config.ExecDirs {
+		if withinDir(filepath.Join(root, allowed), dir) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// chunkRecorder records each write it receives along with the time it arrived
+type chunkRecorder struct {
+	mu     sync.Mutex
+	chunks []string
+	times  []time.Time
+}
+
+func (r *chunkRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.chunks = append(r.chunks, string(p))
+	r.times = append(r.times, time.Now())
+	return len(p), nil
+}
+
+func TestRunCommandStreamsOutput(t *testing.T) {
+	setupWorkspace(t)
+	config.AllowCommands = true
+	config.CommandTimeout = time.Minute
+
+	recorder := &chunkRecorder{}
+	output, err := runCommandStream(recorder, "sh", "-c", "echo first; sleep 0.3; echo second")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != "first\nsecond\n" {
+		t.Errorf("returned output %q, want both lines", output)
+	}
+	if len(recorder.chunks) != 2 || recorder.chunks[0] != "first\n" || recorder.chunks[1] != "second\n" {
+		t.Fatalf("streamed chunks %q, want each line as it was written", recorder.chunks)
+	}
+	if gap := recorder.times[1].Sub(recorder.times[0]); gap < 200*time.Millisecond {
+		t.Errorf("chunks arrived %s apart, want the first streamed before the command finished", gap)
+	}
+}
+
+func TestRunCommandStreamTimeout(t *testing.T) {
+	setupWorkspace(t)
+	config.AllowCommands = true
+	config.CommandTimeout = 50 * time.Millisecond
+
+	recorder := &chunkRecorder{}
+	output, err := runCommandStream(recorder, "sh", "-c", "echo started; exec sleep 5")
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("got error %v, want ErrTimeout", err)
+	}
+	if want := "sh -c echo started; exec sleep 5 did not finish within 50ms: timed out"; err.Error() != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+	if output != "started\n" {
+		t.Errorf("returned output %q, want what was written before the timeout", output)
+	}
+}
+
+func TestCommandDirAllowlist(t *testing.T) {
+	setupGoModule(t, map[string]string{
+		"services/api/main.go": "package main\n\nfunc main() {}\n",
+		"tools/gen/main.go":    "package main\n\nfunc main() {}\n",
+	})
+	config.ExecDirs = []string{"services"}
+
+	tests := []struct {
+		dir     string
+		wantErr error
+	}{
+		{"services/api", nil},
+		{"services", nil},
+		{"tools/gen", ErrExecDirDenied},
+		{".", ErrExecDirDenied},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dir, func(t *testing.T) {
+			setWorkDir(".")
+			if _, err := callTool(t, ChangeDirectory, map[string]any{"path": test.dir}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := runCommand("go", "version")
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
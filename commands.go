@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// slashCommand is a command the user can type at the prompt to control the session rather than talk to Claude
+type slashCommand struct {
+	usage       string
+	description string
+	run         func(a *Agent, args []string) error
+}
+
+var slashCommands = map[string]slashCommand{
+	"/temp": {
+		usage:       "/temp <0-1>",
+		description: "set the sampling temperature for the following turns",
+		run: func(a *Agent, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("usage: /temp <0-1>")
+			}
+			if config.Thinking > 0 {
+				return fmt.Errorf("temperature can't be changed while extended thinking is enabled")
+			}
+			temperature, err := parseTemperature(args[0])
+			if err != nil {
+				return err
+			}
+			a.temperature = &temperature
+			a.commandPrompt(fmt.Sprintf("temperature set to %g", temperature))
+			return nil
+		},
+	},
+	"/model": {
+		usage:       "/model <name|alias>",
+		description: "switch to another model for the following turns",
+		run: func(a *Agent, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("usage: /model <name>")
+			}
+			model := resolveModel(args[0])
+			if config.BetaContext1M && !supportsContext1M(model) {
+				return fmt.Errorf("%s doesn't support the 1M context window of --beta-context-1m", model)
+			}
+			a.model = anthropic.Model(model)
+			a.commandPrompt(fmt.Sprintf("model set to %s", a.model))
+			return nil
+		},
+	},
+	"/tools": {
+		usage:       "/tools",
+		description: "list the tools Claude can use this session",
+		run: func(a *Agent, args []string) error {
+			a.commandPrompt(toolListing(a.tools))
+			return nil
+		},
+	},
+}
+
+// handleCommand runs input as a slash command, reporting whether it was one
+func (a *Agent) handleCommand(input string) bool {
+	fields := strings.Fields(input)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return false
+	}
+
+	command, ok := slashCommands[fields[0]]
+	if !ok {
+		a.commandPrompt(fmt.Sprintf("unknown command %s, available commands:\n%s", fields[0], commandUsages()))
+		return true
+	}
+
+	if err := command.run(a, fields[1:]); err != nil {
+		a.commandPrompt(err.Error())
+	}
+
+	return true
+}
+
+// Command prompt for feedback from slash commands
+func (a *Agent) commandPrompt(message string) {
+	if a.jsonOutput {
+		a.emit(outputEvent{Type: "command", Text: message})
+		return
+	}
+	fmt.Println(a.colorize(ANSI_DIM, message))
+}
+
+func commandUsages() string {
+	var usages []string
+	for _, command := range slashCommands {
+		usages = append(usages, fmt.Sprintf("  %-16s %s", command.usage, command.description))
+	}
+	slices.Sort(usages)
+
+	return strings.Join(usages, "\n")
+}
+
+// toolListing formats the tools as a table of names and the first sentence of their descriptions
+func toolListing(tools []ToolDefinition) string {
+	width := 0
+	for _, tool := range tools {
+		width = max(width, len(tool.Name))
+	}
+
+	lines := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		summary, _, _ := strings.Cut(strings.TrimSpace(tool.Description), ". ")
+		summary, _, _ = strings.Cut(summary, "\n")
+		lines = append(lines, fmt.Sprintf("  %-*s %s", width, tool.Name, strings.TrimSuffix(summary, ".")))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTempAndModelCommands(t *testing.T) {
+	setupWorkspace(t)
+
+	var requests []map[string]any
+	client := fakeAPI(t, func(request map[string]any) string {
+		requests = append(requests, request)
+		return messageJSON(textBlock("ok"))
+	})
+	agent := newTestAgent(client, nil, "/temp 0.3", "/model claude-test-model", "hello")
+
+	captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if agent.temperature == nil || *agent.temperature != 0.3 {
+		t.Errorf("temperature is %v, want 0.3", agent.temperature)
+	}
+	if agent.model != "claude-test-model" {
+		t.Errorf("model is %s, want claude-test-model", agent.model)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if requests[0]["temperature"] != 0.3 || requests[0]["model"] != "claude-test-model" {
+		t.Errorf("request used temperature %v and model %v", requests[0]["temperature"], requests[0]["model"])
+	}
+}
+
+func TestTempCommandValidatesRange(t *testing.T) {
+	setupWorkspace(t)
+	agent := newTestAgent(nil, nil)
+
+	for _, input := range []string{"/temp 1.5", "/temp -0.1", "/temp warm", "/temp"} {
+		output := captureStdout(t, func() {
+			if !agent.handleCommand(input) {
+				t.Errorf("%q was not handled as a command", input)
+			}
+		})
+		if agent.temperature != nil {
+			t.Errorf("%q set the temperature to %v", input, *agent.temperature)
+		}
+		if strings.TrimSpace(output) == "" {
+			t.Errorf("%q printed no error", input)
+		}
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	setupWorkspace(t)
+	agent := newTestAgent(nil, nil)
+
+	output := captureStdout(t, func() {
+		agent.handleCommand("/nope")
+	})
+	if !strings.Contains(output, "unknown command /nope") || !strings.Contains(output, "/temp <0-1>") {
+		t.Errorf("got %q", output)
+	}
+	if agent.handleCommand("not a command") {
+		t.Error("plain input was handled as a command")
+	}
+}
+
+func TestToolsCommandListsTools(t *testing.T) {
+	setupWorkspace(t)
+	tools := []ToolDefinition{ReadFileDefinition, ListFilesDefinition, EditFileDefinition, GlobDefinition}
+	agent := newTestAgent(nil, tools)
+
+	output := captureStdout(t, func() {
+		agent.handleCommand("/tools")
+	})
+
+	for _, tool := range tools {
+		if !strings.Contains(output, "  "+tool.Name+" ") {
+			t.Errorf("%s is missing from the listing:\n%s", tool.Name, output)
+		}
+	}
+	if !strings.Contains(output, "  read_file  Read the contents of a given relative file path\n  list_files List files") {
+		t.Errorf("descriptions are not summarised in aligned columns:\n%s", output)
+	}
+}
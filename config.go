@@ -0,0 +1,384 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Output modes selectable with --output
+const (
+	OutputPretty = "pretty"
+	OutputJSON   = "json"
+)
+
+// Config holds the settings the agent and its tools run with
+type Config struct {
+	Model            string
+	ModelFallbacks   []string
+	Temperature      *float64
+	Thinking         int64
+	MaxTokens        int64
+	StopSequences    []string
+	AllowCommands    bool
+	ExecDirs         []string
+	CommandTimeout   time.Duration
+	ToolTimeout      time.Duration
+	Timeout          time.Duration
+	MaxToolTurns     int
+	MaxToolCalls     int
+	ContextWindow    int64
+	BetaContext1M    bool
+	RPM              int
+	Watch            string
+	MaxFileSize      int64
+	Prompt           string
+	System           string
+	Persona          string
+	FirstUserMessage string
+	Template         string
+	TemplateVars     map[string]string
+	WorkingDir       string
+	Output           string
+	AllowPrivateURLs bool
+	Backup           bool
+	ProtectedPaths   []string
+	GitCheck         bool
+	AutoFormat       bool
+	SoftDelete       bool
+	ContextFiles     []string
+	Quiet            bool
+	JSONPretty       bool
+	EchoInput        bool
+	Width            int
+	Transcript       string
+	Session          string
+	Replay           string
+	NoHistory        bool
+	HistoryLimit     int
+	ExportFormat     string
+	ExportPath       string
+	ReviewResults    bool
+	NoTools          bool
+	IncludeHidden    bool
+	EnabledTools     []string
+	DisabledTools    []string
+	RedactPatterns   []*regexp.Regexp
+	ToolPolicies     map[string]string
+	SkipPermissions  bool
+	BatchApprove     bool
+	Yes              bool
+
+	UserLabel      string
+	AssistantLabel string
+	ToolLabel      string
+	UserColor      string
+	AssistantColor string
+	ToolColor      string
+}
+
+// config is the active configuration, replaced by the parsed command line flags at startup
+var config = DefaultConfig()
+
+// DefaultConfig returns the configuration used when no flags are given
+func DefaultConfig() Config {
+	return Config{
+		Model:          string(anthropic.ModelClaude3_7SonnetLatest),
+		AllowCommands:  false,
+		CommandTimeout: 2 * time.Minute,
+		ToolTimeout:    30 * time.Second,
+		MaxToolTurns:   25,
+		MaxToolCalls:   10,
+		ContextWindow:  200_000,
+		MaxFileSize:    1 << 20,
+		Output:         OutputPretty,
+		UserLabel:      "You",
+		AssistantLabel: "Claude",
+		ToolLabel:      "tool",
+		UserColor:      ANSI_BLUE,
+		AssistantColor: ANSI_YELLOW,
+		ToolColor:      ANSI_GREEN,
+	}
+}
+
+// ParseFlags builds a Config from the command line arguments, starting from the defaults
+func ParseFlags(args []string) (Config, error) {
+	cfg := DefaultConfig()
+
+	fs := flag.NewFlagSet("code-editing-agent", flag.ContinueOnError)
+	fs.StringVar(&cfg.Model, "model", cfg.Model, "the Claude model to chat with, a full model ID or one of the aliases "+modelAliasesHelp())
+	fs.BoolVar(&cfg.BetaContext1M, "beta-context-1m", cfg.BetaContext1M, "use the 1M token context window beta, Sonnet 4 models only")
+	fs.Func("model-fallback", "model to try, in order, when the ones before it are overloaded, may be repeated or comma separated", listFlag(&cfg.ModelFallbacks))
+	fs.Func("temperature", "sampling temperature between 0 and 1, the model default when unset", func(value string) error {
+		temperature, err := parseTemperature(value)
+		if err != nil {
+			return err
+		}
+		cfg.Temperature = &temperature
+		return nil
+	})
+	fs.Int64Var(&cfg.Thinking, "thinking", cfg.Thinking, "enable extended thinking with this token budget, at least "+strconv.Itoa(minThinkingBudget)+", 0 to disable")
+	fs.Int64Var(&cfg.Thinking, "thinking-budget", cfg.Thinking, "same as --thinking")
+	fs.Int64Var(&cfg.MaxTokens, "max-tokens", cfg.MaxTokens, "most tokens Claude may generate per response, thinking included, 0 for "+strconv.Itoa(defaultMaxTokens)+" plus any thinking budget")
+	fs.Func("stop", "custom text that makes Claude stop generating when it is produced, may be repeated", func(sequence string) error {
+		// Taken verbatim rather than split on commas as a marker may well contain one
+		if strings.TrimSpace(sequence) == "" {
+			return fmt.Errorf("stop sequence must not be empty")
+		}
+		cfg.StopSequences = append(cfg.StopSequences, sequence)
+		return nil
+	})
+	fs.BoolVar(&cfg.AllowCommands, "allow-commands", cfg.AllowCommands, "allow tools that execute commands such as go build")
+	fs.Func("exec-dir", "directory, relative to the workspace root, that commands may run in, may be repeated (default anywhere)", listFlag(&cfg.ExecDirs))
+	fs.DurationVar(&cfg.CommandTimeout, "command-timeout", cfg.CommandTimeout, "maximum time a command tool may run for")
+	fs.DurationVar(&cfg.Timeout, "timeout", cfg.Timeout, "end the session once this long has passed, after the turn in progress finishes, 0 for no limit")
+	fs.DurationVar(&cfg.ToolTimeout, "tool-timeout", cfg.ToolTimeout, "maximum time any other tool may run for, 0 for no limit")
+	fs.IntVar(&cfg.MaxToolTurns, "max-tool-turns", cfg.MaxToolTurns, "most consecutive tool calls Claude may make before waiting for user input, 0 for no limit")
+	fs.IntVar(&cfg.MaxToolCalls, "max-tool-calls", cfg.MaxToolCalls, "most tool calls run from a single Claude message, 0 for no limit")
+	fs.IntVar(&cfg.RPM, "rpm", cfg.RPM, "most requests sent to Claude per minute, waiting for a slot when exceeded, 0 for no limit")
+	fs.Int64Var(&cfg.MaxFileSize, "max-file-size", cfg.MaxFileSize, "largest file in bytes read_file will return without allow_large")
+	fs.StringVar(&cfg.Watch, "watch", cfg.Watch, "shell command to re-run whenever files in the workspace change, printing its output")
+	fs.StringVar(&cfg.FirstUserMessage, "first-user-message", cfg.FirstUserMessage, "send this message first, then carry on reading input interactively")
+	fs.StringVar(&cfg.Template, "template", cfg.Template, "send this prompt template, a file or a name in "+templateDir+", as the first message")
+	fs.Func("var", "name=value to fill in {{.name}} in the --template, may be repeated", varFlag(&cfg.TemplateVars))
+	fs.StringVar(&cfg.WorkingDir, "working-dir", cfg.WorkingDir, "run against this directory instead of the current one, it becomes the root tools are confined to")
+	fs.StringVar(&cfg.WorkingDir, "C", cfg.WorkingDir, "shorthand for --working-dir")
+	fs.StringVar(&cfg.System, "system", cfg.System, "text to add to the system prompt, after any --persona")
+	fs.StringVar(&cfg.Persona, "persona", cfg.Persona, "start the system prompt with a preset: "+personaNamesHelp()+", or a name in "+personaDir)
+	fs.StringVar(&cfg.Prompt, "prompt", cfg.Prompt, "run a single non-interactive turn with this prompt and exit")
+	fs.StringVar(&cfg.Output, "output", cfg.Output, "output format: pretty for humans or json for one event per line")
+	fs.BoolVar(&cfg.AllowPrivateURLs, "allow-private-urls", cfg.AllowPrivateURLs, "allow fetch_url to reach localhost and private network addresses")
+	fs.BoolVar(&cfg.Backup, "backup", cfg.Backup, "copy files to <path>.bak before tools modify them")
+	fs.Func("protect", "glob of files tools must never change, such as '*.pem', may be repeated", listFlag(&cfg.ProtectedPaths))
+	safeMode := fs.Bool("safe-mode", false, "protect secrets, go.sum and CI configuration from changes: "+strings.Join(safeModePatterns, ", "))
+	fs.BoolVar(&cfg.GitCheck, "git-check", cfg.GitCheck, "ask before tools change files that are untracked or have uncommitted changes in git")
+	fs.BoolVar(&cfg.AutoFormat, "autofmt", cfg.AutoFormat, "gofmt .go files after tools edit them")
+	fs.BoolVar(&cfg.SoftDelete, "soft-delete", cfg.SoftDelete, "make delete_file move files to "+trashDir+"/ instead of removing them")
+	fs.Func("context", "glob of files to include in the system prompt, may be repeated", func(pattern string) error {
+		cfg.ContextFiles = append(cfg.ContextFiles, pattern)
+		return nil
+	})
+	fs.BoolVar(&cfg.Quiet, "quiet", cfg.Quiet, "hide tool call lines and other decoration, showing only Claude's responses (json output is unaffected)")
+	fs.BoolVar(&cfg.JSONPretty, "json-pretty", cfg.JSONPretty, "indent the JSON input shown on tool call lines")
+	fs.BoolVar(&cfg.EchoInput, "echo-input", cfg.EchoInput, "print each line of input after the prompt, for piped input and logs")
+	fs.IntVar(&cfg.Width, "width", cfg.Width, "wrap Claude's responses to this many columns, 0 to fit the terminal, -1 to never wrap")
+	fs.StringVar(&cfg.Transcript, "transcript", cfg.Transcript, "write the conversation to this file as Markdown on exit")
+	fs.StringVar(&cfg.Session, "session", cfg.Session, "resume the conversation saved in this file, saving it back on exit")
+	fs.IntVar(&cfg.HistoryLimit, "history-limit", cfg.HistoryLimit, "most recent messages kept when saving the session, 0 for no limit")
+	fs.StringVar(&cfg.Replay, "replay", cfg.Replay, "re-run the prompts of a session file, answering with its recorded replies instead of calling Claude")
+	fs.BoolVar(&cfg.NoHistory, "no-history", cfg.NoHistory, "write nothing about the conversation to disk: no transcript, export or session save")
+	fs.Func("export", "write the conversation on exit as format=path, where format is "+ExportOpenAI, exportFlag(&cfg))
+	fs.BoolVar(&cfg.ReviewResults, "review-results", cfg.ReviewResults, "show each tool result and offer to attach a note before it is sent to Claude")
+	fs.BoolVar(&cfg.IncludeHidden, "include-hidden", cfg.IncludeHidden, "make list_files include hidden files and directories by default")
+	fs.BoolVar(&cfg.NoTools, "no-tools", cfg.NoTools, "offer Claude no tools at all, for plain chat")
+	fs.Func("enable-tool", "only offer the named tool to Claude, may be repeated to enable several", listFlag(&cfg.EnabledTools))
+	fs.Func("disable-tool", "never offer the named tool to Claude, may be repeated", listFlag(&cfg.DisabledTools))
+	fs.Func("redact", "regular expression for additional secrets to mask in logs, may be repeated", func(expr string) error {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return err
+		}
+		cfg.RedactPatterns = append(cfg.RedactPatterns, pattern)
+		return nil
+	})
+	fs.Func("tool-policy", "name=allow|ask|deny to always run, confirm or refuse a tool, may be repeated", policyFlag(&cfg.ToolPolicies))
+	fs.BoolVar(&cfg.SkipPermissions, "dangerously-skip-permissions", cfg.SkipPermissions, "allow every tool and command without asking, for trusted automated runs")
+	fs.BoolVar(&cfg.BatchApprove, "batch-approve", cfg.BatchApprove, "ask once about all the changes in a Claude message that the ask policy would confirm one by one")
+	fs.BoolVar(&cfg.Yes, "yes", cfg.Yes, "don't ask to confirm --dangerously-skip-permissions")
+	fs.StringVar(&cfg.UserLabel, "user-label", cfg.UserLabel, "label shown before your input")
+	fs.StringVar(&cfg.AssistantLabel, "assistant-label", cfg.AssistantLabel, "label shown before Claude's responses")
+	fs.StringVar(&cfg.ToolLabel, "tool-label", cfg.ToolLabel, "label shown before tool calls")
+	fs.Func("user-color", "color of the user label: "+colorNamesHelp(), colorFlag(&cfg.UserColor))
+	fs.Func("assistant-color", "color of the assistant label: "+colorNamesHelp(), colorFlag(&cfg.AssistantColor))
+	fs.Func("tool-color", "color of the tool label: "+colorNamesHelp(), colorFlag(&cfg.ToolColor))
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg.Model = resolveModel(cfg.Model)
+	for i, model := range cfg.ModelFallbacks {
+		cfg.ModelFallbacks[i] = resolveModel(model)
+	}
+	if *safeMode {
+		cfg.ProtectedPaths = append(cfg.ProtectedPaths, safeModePatterns...)
+	}
+	if cfg.SkipPermissions {
+		skipPermissions(&cfg)
+	}
+	if cfg.NoHistory {
+		disableHistory(&cfg)
+	}
+	if cfg.BetaContext1M {
+		cfg.ContextWindow = context1MWindow
+	}
+
+	if err := validateConfig(cfg); err != nil {
+		// Report invalid values the same way the flag package reports malformed ones
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// disableHistory turns off everything that would record the conversation on disk. A session file is still
+// read so a saved conversation can be continued privately, it just isn't written back.
+func disableHistory(cfg *Config) {
+	cfg.Transcript = ""
+	cfg.ExportFormat = ""
+	cfg.ExportPath = ""
+}
+
+// defaultMaxTokens is the response length allowed when --max-tokens isn't given, on top of any thinking budget
+const defaultMaxTokens = 1024
+
+// minThinkingBudget is the smallest extended thinking budget the API accepts
+const minThinkingBudget = 1024
+
+// validateConfig checks flag values that parse correctly but are not meaningful
+func validateConfig(cfg Config) error {
+	if cfg.Output != OutputPretty && cfg.Output != OutputJSON {
+		return fmt.Errorf("invalid --output %q: must be %q or %q", cfg.Output, OutputPretty, OutputJSON)
+	}
+	if cfg.HistoryLimit < 0 {
+		return fmt.Errorf("invalid --history-limit %d: must not be negative", cfg.HistoryLimit)
+	}
+	if cfg.RPM < 0 {
+		return fmt.Errorf("invalid --rpm %d: must not be negative", cfg.RPM)
+	}
+	if cfg.WorkingDir != "" {
+		info, err := os.Stat(cfg.WorkingDir)
+		if err != nil {
+			return fmt.Errorf("invalid --working-dir: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid --working-dir %q: not a directory", cfg.WorkingDir)
+		}
+	}
+	if cfg.Replay != "" && (cfg.Prompt != "" || cfg.FirstUserMessage != "" || cfg.Template != "" || cfg.Session != "") {
+		return fmt.Errorf("--replay can't be used with --prompt, --first-user-message, --template or --session")
+	}
+	if cfg.Template != "" && (cfg.Prompt != "" || cfg.FirstUserMessage != "") {
+		return fmt.Errorf("--template can't be used with --prompt or --first-user-message")
+	}
+	if cfg.BetaContext1M {
+		for _, model := range append([]string{cfg.Model}, cfg.ModelFallbacks...) {
+			if !supportsContext1M(model) {
+				return fmt.Errorf("--beta-context-1m can't be used with %s, only Sonnet 4 models support it", model)
+			}
+		}
+	}
+	if cfg.Thinking != 0 && cfg.Thinking < minThinkingBudget {
+		return fmt.Errorf("invalid --thinking %d: the budget must be at least %d tokens", cfg.Thinking, minThinkingBudget)
+	}
+	if cfg.MaxTokens < 0 {
+		return fmt.Errorf("invalid --max-tokens %d: must not be negative", cfg.MaxTokens)
+	}
+	if cfg.Thinking != 0 && cfg.MaxTokens != 0 && cfg.Thinking >= cfg.MaxTokens {
+		return fmt.Errorf("invalid --thinking %d: the budget must be below --max-tokens %d to leave room for the answer", cfg.Thinking, cfg.MaxTokens)
+	}
+	if cfg.Thinking != 0 && cfg.Temperature != nil {
+		return fmt.Errorf("--temperature can't be used with --thinking")
+	}
+
+	return nil
+}
+
+// parseTemperature parses a sampling temperature, which the API accepts between 0 and 1
+func parseTemperature(value string) (float64, error) {
+	temperature, err := strconv.ParseFloat(value, 64)
+	if err != nil || temperature < 0 || temperature > 1 {
+		return 0, fmt.Errorf("temperature must be a number between 0 and 1, got %q", value)
+	}
+
+	return temperature, nil
+}
+
+// context1MWindow is the context window with --beta-context-1m
+const context1MWindow = 1_000_000
+
+// supportsContext1M reports whether the model accepts the 1M token context window beta
+func supportsContext1M(model string) bool {
+	return strings.HasPrefix(model, "claude-sonnet-4")
+}
+
+// modelAliases maps short model names to the full model IDs they stand for
+var modelAliases = map[string]anthropic.Model{
+	"sonnet":     anthropic.ModelClaudeSonnet4_5,
+	"sonnet-3.7": anthropic.ModelClaude3_7SonnetLatest,
+	"opus":       anthropic.ModelClaudeOpus4_1_20250805,
+	"haiku":      anthropic.ModelClaude3_5HaikuLatest,
+}
+
+// resolveModel expands a model alias to its full ID, passing anything else through unchanged so raw IDs still work
+func resolveModel(name string) string {
+	if model, ok := modelAliases[strings.ToLower(name)]; ok {
+		return string(model)
+	}
+
+	return name
+}
+
+func modelAliasesHelp() string {
+	aliases := make([]string, 0, len(modelAliases))
+	for alias := range modelAliases {
+		aliases = append(aliases, alias)
+	}
+	slices.Sort(aliases)
+
+	return strings.Join(aliases, ", ")
+}
+
+// listFlag appends each occurrence of a repeatable flag to target, also splitting comma separated values
+func listFlag(target *[]string) func(string) error {
+	return func(value string) error {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*target = append(*target, item)
+			}
+		}
+		return nil
+	}
+}
+
+// labelColors maps the color names accepted on the command line to ANSI codes, with none disabling color
+var labelColors = map[string]string{
+	"none":    "",
+	"red":     ANSI_RED,
+	"green":   ANSI_GREEN,
+	"yellow":  ANSI_YELLOW,
+	"blue":    ANSI_BLUE,
+	"magenta": "\u001b[95m",
+	"cyan":    "\u001b[96m",
+}
+
+// colorFlag parses a color name into the ANSI code stored at target
+func colorFlag(target *string) func(string) error {
+	return func(name string) error {
+		code, ok := labelColors[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("unknown color %q, expected one of %s", name, colorNamesHelp())
+		}
+		*target = code
+		return nil
+	}
+}
+
+func colorNamesHelp() string {
+	names := make([]string, 0, len(labelColors))
+	for name := range labelColors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestResolveModel(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "sonnet", want: string(anthropic.ModelClaudeSonnet4_5)},
+		{name: "Opus", want: string(anthropic.ModelClaudeOpus4_1_20250805)},
+		{name: "haiku", want: string(anthropic.ModelClaude3_5HaikuLatest)},
+		{name: "claude-3-7-sonnet-latest", want: "claude-3-7-sonnet-latest"},
+		{name: "some-future-model", want: "some-future-model"},
+	}
+
+	for _, test := range tests {
+		if got := resolveModel(test.name); got != test.want {
+			t.Errorf("resolveModel(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestModelFlagResolvesAliases(t *testing.T) {
+	cfg, err := ParseFlags([]string{"--model", "haiku", "--model-fallback", "sonnet,raw-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Model != string(anthropic.ModelClaude3_5HaikuLatest) {
+		t.Errorf("model is %s", cfg.Model)
+	}
+	if len(cfg.ModelFallbacks) != 2 || cfg.ModelFallbacks[0] != string(anthropic.ModelClaudeSonnet4_5) || cfg.ModelFallbacks[1] != "raw-model" {
+		t.Errorf("fallbacks are %v", cfg.ModelFallbacks)
+	}
+}
+
+func TestStopFlag(t *testing.T) {
+	cfg, err := ParseFlags([]string{"--stop", "END", "--stop", "a, b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.StopSequences, []string{"END", "a, b"}) {
+		t.Errorf("stop sequences are %q", cfg.StopSequences)
+	}
+
+	if _, err := ParseFlags([]string{"--stop", " "}); err == nil {
+		t.Error("an empty stop sequence was accepted")
+	}
+}
+
+func TestThinkingBudgetFlag(t *testing.T) {
+	cfg, err := ParseFlags([]string{"--thinking-budget", "2048", "--max-tokens", "4096"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Thinking != 2048 || cfg.MaxTokens != 4096 {
+		t.Errorf("thinking budget is %d and max tokens %d", cfg.Thinking, cfg.MaxTokens)
+	}
+
+	for _, args := range [][]string{
+		{"--thinking-budget", "512"},
+		{"--thinking-budget", "4096", "--max-tokens", "4096"},
+		{"--thinking-budget", "2048", "--max-tokens", "1024"},
+		{"--max-tokens", "-1"},
+	} {
+		if _, err := ParseFlags(args); err == nil {
+			t.Errorf("%v was accepted", args)
+		}
+	}
+}
+
+func TestBetaContext1MFlag(t *testing.T) {
+	cfg, err := ParseFlags([]string{"--beta-context-1m", "--model", "sonnet"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ContextWindow != context1MWindow {
+		t.Errorf("context window is %d, want %d", cfg.ContextWindow, context1MWindow)
+	}
+
+	for _, args := range [][]string{
+		{"--beta-context-1m", "--model", "haiku"},
+		{"--beta-context-1m", "--model", "sonnet", "--model-fallback", "opus"},
+	} {
+		if _, err := ParseFlags(args); err == nil {
+			t.Errorf("%v was accepted", args)
+		}
+	}
+}
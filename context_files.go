@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadContextFiles reads the workspace files matching the given globs and formats them, each under a header
+// naming the file, for inclusion in the system prompt
+func loadContextFiles(patterns []string) (string, error) {
+	var out strings.Builder
+	seen := map[string]bool{}
+
+	for _, pattern := range patterns {
+		matches, err := globFiles(strings.TrimPrefix(pattern, "./"))
+		if err != nil {
+			return "", fmt.Errorf("context %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: context pattern %q matched no files\n", pattern)
+		}
+
+		for _, match := range matches {
+			if seen[match] {
+				continue
+			}
+			seen[match] = true
+
+			content, err := readSandboxedTextFile(filepath.FromSlash(match), false)
+			if err != nil {
+				return "", fmt.Errorf("context file %s: %w", match, err)
+			}
+
+			if out.Len() == 0 {
+				out.WriteString("The following project files are always provided for context.\n")
+			}
+			fmt.Fprintf(&out, "\n<file path=%q>\n%s\n</file>\n", match, strings.TrimRight(content, "\n"))
+		}
+	}
+
+	return out.String(), nil
+}
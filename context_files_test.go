@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestContextFilesInSystemBlock(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "AGENTS.md", "Always run the tests.\n")
+	writeFile(t, "docs/style.md", "Use tabs.\n")
+
+	contextFiles, err := loadContextFiles([]string{"AGENTS.md", "docs/*.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	system, err := systemPrompt("", "", contextFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := newTestAgent(nil, nil)
+	agent.system = system
+	blocks := agent.systemBlocks()
+	if len(blocks) != 1 {
+		t.Fatalf("got %d system blocks, want 1", len(blocks))
+	}
+
+	for _, want := range []string{
+		"<file path=\"AGENTS.md\">\nAlways run the tests.\n</file>",
+		"<file path=\"docs/style.md\">\nUse tabs.\n</file>",
+	} {
+		if !strings.Contains(blocks[0].Text, want) {
+			t.Errorf("system block is missing %q:\n%s", want, blocks[0].Text)
+		}
+	}
+}
+
+func TestContextFilesSizeCap(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxFileSize = 8
+	writeFile(t, "AGENTS.md", "far more than eight bytes\n")
+
+	_, err := loadContextFiles([]string{"AGENTS.md"})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("got error %v, want ErrFileTooLarge", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// charsPerToken is the rough average number of characters per token for English text and code
+const charsPerToken = 4
+
+// NewCountTokensDefinition creates the count_tokens tool, using the token counting endpoint when a client is given
+// and falling back to a character based estimate otherwise
+func NewCountTokensDefinition(client *anthropic.Client) ToolDefinition {
+	return ToolDefinition{
+		Name:        "count_tokens",
+		Description: "Count the number of tokens in a file or a piece of text. Use this before reading a large file to decide whether to read it fully or in parts. Provide either path or text.",
+		InputSchema: CountTokensInputSchema,
+		Function: func(input json.RawMessage) (string, error) {
+			return CountTokens(client, input)
+		},
+	}
+}
+
+type CountTokensInput struct {
+	Path string `json:"path,omitempty" jsonschema_description:"The relative path of a file to count the tokens of."`
+	Text string `json:"text,omitempty" jsonschema_description:"Raw text to count the tokens of, used when no path is given."`
+}
+
+var CountTokensInputSchema = GenerateSchema[CountTokensInput]()
+
+func CountTokens(client *anthropic.Client, input json.RawMessage) (string, error) {
+	countTokensInput := CountTokensInput{}
+	err := json.Unmarshal(input, &countTokensInput)
+	if err != nil {
+		return "", err
+	}
+
+	text := countTokensInput.Text
+	if countTokensInput.Path != "" {
+		text, err = readSandboxedTextFile(countTokensInput.Path, false)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if text == "" {
+		return "0 tokens", nil
+	}
+
+	if client != nil {
+		count, err := client.Messages.CountTokens(context.TODO(), anthropic.MessageCountTokensParams{
+			Model:    anthropic.Model(config.Model),
+			Messages: []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(text))},
+		})
+		if err == nil {
+			return fmt.Sprintf("%d tokens", count.InputTokens), nil
+		}
+	}
+
+	return fmt.Sprintf("approximately %d tokens (estimated)", estimateTokens(text)), nil
+}
+
+// formatContextStatus describes how much of the context window the conversation uses, e.g. [context: 12k/200k]
+func formatContextStatus(used, window int64) string {
+	return fmt.Sprintf("[context: %s/%s]", formatTokenCount(used), formatTokenCount(window))
+}
+
+// formatTokenCount abbreviates a token count to thousands or millions
+func formatTokenCount(tokens int64) string {
+	switch {
+	case tokens >= 1_000_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(tokens)/1_000_000), ".0") + "M"
+	case tokens >= 1_000:
+		return fmt.Sprintf("%dk", (tokens+500)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+}
+
+// estimateTokens approximates the token count of text from its character count
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
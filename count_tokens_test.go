@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"héllo wörld!", 3},
+	}
+
+	for _, test := range tests {
+		if got := estimateTokens(test.text); got != test.want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", test.text, got, test.want)
+		}
+	}
+}
+
+func TestCountTokensEstimate(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", "0123456789abcdef")
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  string
+	}{
+		{name: "text", input: map[string]any{"text": "12345678"}, want: "approximately 2 tokens (estimated)"},
+		{name: "file", input: map[string]any{"path": "file.txt"}, want: "approximately 4 tokens (estimated)"},
+		{name: "empty", input: map[string]any{}, want: "0 tokens"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := callTool(t, NewCountTokensDefinition(nil).Function, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatContextStatus(t *testing.T) {
+	tests := []struct {
+		used, window int64
+		want         string
+	}{
+		{0, 200_000, "[context: 0/200k]"},
+		{950, 200_000, "[context: 950/200k]"},
+		{12_345, 200_000, "[context: 12k/200k]"},
+		{199_600, 200_000, "[context: 200k/200k]"},
+		{250_000, 1_000_000, "[context: 250k/1M]"},
+		{1_500_000, 2_000_000, "[context: 1.5M/2M]"},
+	}
+
+	for _, test := range tests {
+		if got := formatContextStatus(test.used, test.window); got != test.want {
+			t.Errorf("formatContextStatus(%d, %d) = %q, want %q", test.used, test.window, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var CreateFileDefinition = ToolDefinition{
+	Name:        "create_file",
+	Description: "Create a new file with the given content, creating parent directories as needed. Fails if the file already exists, so it can never overwrite anything; use edit_file or replace_file to change existing files.",
+	InputSchema: CreateFileInputSchema,
+	Function:    CreateFile,
+	Mutates:     true,
+}
+
+type CreateFileInput struct {
+	Path    string `json:"path" jsonschema_description:"The relative path of the file to create."`
+	Content string `json:"content" jsonschema_description:"The content of the new file."`
+}
+
+var CreateFileInputSchema = GenerateSchema[CreateFileInput]()
+
+func CreateFile(input json.RawMessage) (string, error) {
+	createFileInput := CreateFileInput{}
+	err := json.Unmarshal(input, &createFileInput)
+	if err != nil {
+		return "", err
+	}
+
+	if createFileInput.Path == "" {
+		return "", fmt.Errorf("path must not be empty: %w", ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(createFileInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(filepath.Dir(resolved), 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// O_EXCL makes the existence check and the create one step, so nothing written in between is clobbered
+	file, err := os.OpenFile(resolved, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return "", fmt.Errorf("%s already exists, use edit_file or replace_file to change it: %w", createFileInput.Path, ErrConflict)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	formatted, note := autoFormat(resolved, []byte(createFileInput.Content))
+	_, err = file.Write(formatted)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return fmt.Sprintf("Created %s (%d bytes)%s", createFileInput.Path, len(formatted), note), nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCreateFile(t *testing.T) {
+	setupWorkspace(t)
+
+	got, err := callTool(t, CreateFile, map[string]any{"path": "pkg/new.txt", "content": "fresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Created pkg/new.txt (5 bytes)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if content := readFile(t, "pkg/new.txt"); content != "fresh" {
+		t.Errorf("file has %q", content)
+	}
+}
+
+func TestCreateFileRefusesExisting(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "existing.txt", "keep me")
+
+	_, err := callTool(t, CreateFile, map[string]any{"path": "existing.txt", "content": "clobbered"})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("got error %v, want ErrConflict", err)
+	}
+	if content := readFile(t, "existing.txt"); content != "keep me" {
+		t.Errorf("existing file was changed to %q", content)
+	}
+}
+
+func TestCreateFileRejections(t *testing.T) {
+	setupWorkspace(t)
+
+	tests := []struct {
+		path string
+		want error
+	}{
+		{"", ErrInvalidInput},
+		{"../outside.txt", ErrOutsideWorkspace},
+	}
+
+	for _, test := range tests {
+		if _, err := callTool(t, CreateFile, map[string]any{"path": test.path, "content": "x"}); !errors.Is(err, test.want) {
+			t.Errorf("%q: got error %v, want %v", test.path, err, test.want)
+		}
+	}
+	if _, err := os.Stat("../outside.txt"); err == nil {
+		t.Error("a file was created outside the workspace")
+	}
+}
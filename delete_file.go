@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// trashDir is where --soft-delete moves deleted files, relative to the workspace root
+const trashDir = ".trash"
+
+var DeleteFileDefinition = ToolDefinition{
+	Name:        "delete_file",
+	Description: "Delete a file. Directories can't be deleted with this tool. Depending on the agent's settings the file may be moved to a trash directory from which restore_file can bring it back.",
+	InputSchema: DeleteFileInputSchema,
+	Function:    DeleteFile,
+	Mutates:     true,
+}
+
+type DeleteFileInput struct {
+	Path string `json:"path" jsonschema_description:"The relative path of the file to delete."`
+}
+
+var DeleteFileInputSchema = GenerateSchema[DeleteFileInput]()
+
+func DeleteFile(input json.RawMessage) (string, error) {
+	deleteFileInput := DeleteFileInput{}
+	err := json.Unmarshal(input, &deleteFileInput)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := resolvePath(deleteFileInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory: %w", deleteFileInput.Path, ErrInvalidInput)
+	}
+
+	if !config.SoftDelete {
+		err = os.Remove(resolved)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Deleted %s", deleteFileInput.Path), nil
+	}
+
+	trashed, err := moveToTrash(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Moved %s to %s, use restore_file to bring it back", deleteFileInput.Path, trashed), nil
+}
+
+// moveToTrash moves a file into the trash directory under its workspace relative path, adding a numeric suffix
+// when an earlier deletion of the same path is already there. It returns the relative trash path.
+func moveToTrash(filePath string) (string, error) {
+	root, err := workspaceRoot()
+	if err != nil {
+		return "", err
+	}
+
+	relPath, err := filepath.Rel(root, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(root, trashDir, relPath)
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(target); os.IsNotExist(err) {
+			break
+		}
+		target = filepath.Join(root, trashDir, relPath) + "." + strconv.Itoa(n)
+	}
+
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(filePath, target)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Rel(root, target)
+}
+
+var RestoreFileDefinition = ToolDefinition{
+	Name:        "restore_file",
+	Description: "Restore a file previously deleted with delete_file while soft delete is enabled, putting the most recently deleted copy back at its original path.",
+	InputSchema: RestoreFileInputSchema,
+	Function:    RestoreFile,
+}
+
+type RestoreFileInput struct {
+	Path string `json:"path" jsonschema_description:"The original relative path of the deleted file."`
+}
+
+var RestoreFileInputSchema = GenerateSchema[RestoreFileInput]()
+
+func RestoreFile(input json.RawMessage) (string, error) {
+	restoreFileInput := RestoreFileInput{}
+	err := json.Unmarshal(input, &restoreFileInput)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := resolvePath(restoreFileInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Lstat(resolved); err == nil {
+		return "", fmt.Errorf("%s already exists, move it out of the way before restoring", restoreFileInput.Path)
+	}
+
+	root, err := workspaceRoot()
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(root, resolved)
+	if err != nil {
+		return "", err
+	}
+
+	trashed, err := latestTrashed(filepath.Join(root, trashDir, relPath))
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(filepath.Dir(resolved), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(trashed, resolved)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Restored %s", restoreFileInput.Path), nil
+}
+
+// latestTrashed finds the most recently trashed copy of a file, which carries the highest numeric suffix
+func latestTrashed(trashPath string) (string, error) {
+	entries, err := os.ReadDir(filepath.Dir(trashPath))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	base := filepath.Base(trashPath)
+	latest, latestN := "", -1
+	for _, entry := range entries {
+		name := entry.Name()
+		n := 0
+		if name != base {
+			suffix, ok := strings.CutPrefix(name, base+".")
+			if !ok {
+				continue
+			}
+			if n, err = strconv.Atoi(suffix); err != nil {
+				continue
+			}
+		}
+		if n > latestN {
+			latest, latestN = filepath.Join(filepath.Dir(trashPath), name), n
+		}
+	}
+
+	if latest == "" {
+		return "", fmt.Errorf("no deleted copy of %s in %s: %w", filepath.Base(trashPath), trashDir, ErrNotFound)
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestDeleteFile(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", "content")
+
+	if _, err := callTool(t, DeleteFile, map[string]any{"path": "file.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("file.txt"); !os.IsNotExist(err) {
+		t.Errorf("file still exists: %v", err)
+	}
+	if _, err := os.Stat(trashDir); !os.IsNotExist(err) {
+		t.Error("a hard delete used the trash")
+	}
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	setupWorkspace(t)
+	config.SoftDelete = true
+	writeFile(t, "dir/file.txt", "first")
+
+	got, err := callTool(t, DeleteFile, map[string]any{"path": "dir/file.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Moved dir/file.txt to .trash/dir/file.txt, use restore_file to bring it back" {
+		t.Errorf("got %q", got)
+	}
+
+	// A second deletion of the same path is kept alongside the first
+	writeFile(t, "dir/file.txt", "second")
+	if _, err := callTool(t, DeleteFile, map[string]any{"path": "dir/file.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, ".trash/dir/file.txt") != "first" || readFile(t, ".trash/dir/file.txt.1") != "second" {
+		t.Error("trashed copies were not kept under distinct names")
+	}
+
+	if _, err := callTool(t, RestoreFile, map[string]any{"path": "dir/file.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, "dir/file.txt"); got != "second" {
+		t.Errorf("restored %q, want the most recent deletion", got)
+	}
+
+	if _, err := callTool(t, RestoreFile, map[string]any{"path": "dir/file.txt"}); err == nil {
+		t.Error("restored over an existing file")
+	}
+}
+
+func TestRestoreFileNotTrashed(t *testing.T) {
+	setupWorkspace(t)
+	config.SoftDelete = true
+
+	_, err := callTool(t, RestoreFile, map[string]any{"path": "never.txt"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestGlobSkipsTrash(t *testing.T) {
+	setupWorkspace(t)
+	config.SoftDelete = true
+	writeFile(t, "keep.go", "package main\n")
+	writeFile(t, "gone.go", "package main\n")
+
+	if _, err := callTool(t, DeleteFile, map[string]any{"path": "gone.go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := globFiles("**/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0] != "keep.go" {
+		t.Errorf("got %v, want only keep.go", matches)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var DeleteLinesDefinition = ToolDefinition{
+	Name:        "delete_lines",
+	Description: "Delete a range of lines from a file. Lines are 1-based and the range is inclusive, so start_line 3 and end_line 5 removes lines 3, 4 and 5.",
+	InputSchema: DeleteLinesInputSchema,
+	Function:    DeleteLines,
+	Mutates:     true,
+}
+
+type DeleteLinesInput struct {
+	Path      string `json:"path" jsonschema_description:"The relative path of the file to delete lines from."`
+	StartLine int    `json:"start_line" jsonschema_description:"The first line to delete (1-based)."`
+	EndLine   int    `json:"end_line" jsonschema_description:"The last line to delete (1-based, inclusive)."`
+}
+
+var DeleteLinesInputSchema = GenerateSchema[DeleteLinesInput]()
+
+func DeleteLines(input json.RawMessage) (string, error) {
+	deleteLinesInput := DeleteLinesInput{}
+	err := json.Unmarshal(input, &deleteLinesInput)
+	if err != nil {
+		return "", err
+	}
+
+	if deleteLinesInput.Path == "" {
+		return "", fmt.Errorf("invalid input parameters: %w", ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(deleteLinesInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := readTextFile(resolved, false)
+	if err != nil {
+		return "", err
+	}
+
+	// Split keeping the line terminators so the rest of the file is written back byte for byte
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	start, end := deleteLinesInput.StartLine, deleteLinesInput.EndLine
+	if start < 1 || end < start || end > len(lines) {
+		return "", fmt.Errorf("invalid line range %d-%d, file has %d lines: %w", start, end, len(lines), ErrInvalidInput)
+	}
+
+	newContent := strings.Join(lines[:start-1], "") + strings.Join(lines[end:], "")
+
+	err = backupFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	formatted, note := autoFormat(resolved, []byte(newContent))
+	err = writeFileAtomic(resolved, formatted, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Deleted lines %d-%d from %s%s", start, end, deleteLinesInput.Path, note), nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeleteLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end int
+		want       string
+	}{
+		{name: "single line", start: 2, end: 2, want: "one\nthree\nfour\n"},
+		{name: "range", start: 2, end: 3, want: "one\nfour\n"},
+		{name: "whole file", start: 1, end: 4, want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "file.txt", "one\ntwo\nthree\nfour\n")
+
+			_, err := callTool(t, DeleteLines, map[string]any{"path": "file.txt", "start_line": test.start, "end_line": test.end})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readFile(t, "file.txt"); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDeleteLinesOutOfBounds(t *testing.T) {
+	for _, lines := range [][2]int{{3, 9}, {0, 1}, {3, 2}} {
+		setupWorkspace(t)
+		writeFile(t, "file.txt", "one\ntwo\nthree\n")
+
+		_, err := callTool(t, DeleteLines, map[string]any{"path": "file.txt", "start_line": lines[0], "end_line": lines[1]})
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("lines %d-%d: got error %v, want ErrInvalidInput", lines[0], lines[1], err)
+		}
+		if got := readFile(t, "file.txt"); got != "one\ntwo\nthree\n" {
+			t.Errorf("lines %d-%d: file changed to %q", lines[0], lines[1], got)
+		}
+	}
+}
+
+func TestDeleteLinesFollowsWorkingDirectory(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "sub/file.txt", "one\ntwo\n")
+	writeFile(t, "file.txt", "top\nlevel\n")
+
+	if _, err := callTool(t, ChangeDirectory, map[string]any{"path": "sub"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callTool(t, DeleteLines, map[string]any{"path": "file.txt", "start_line": 1, "end_line": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, "sub/file.txt"); got != "two\n" {
+		t.Errorf("sub/file.txt has %q", got)
+	}
+	if got := readFile(t, "file.txt"); got != "top\nlevel\n" {
+		t.Errorf("the workspace root file was changed to %q", got)
+	}
+}
+
+func TestDeleteLinesOutsideWorkspace(t *testing.T) {
+	setupWorkspace(t)
+
+	_, err := callTool(t, DeleteLines, map[string]any{"path": "../file.txt", "start_line": 1, "end_line": 1})
+	if !errors.Is(err, ErrOutsideWorkspace) {
+		t.Errorf("got error %v, want ErrOutsideWorkspace", err)
+	}
+}
+
+func TestDeleteLinesProtected(t *testing.T) {
+	setupWorkspace(t)
+	config.ProtectedPaths = []string{"go.sum"}
+	writeFile(t, "sub/go.sum", "one\ntwo\n")
+	agent := newTestAgent(nil, []ToolDefinition{ChangeDirectoryDefinition, DeleteLinesDefinition})
+
+	captureStdout(t, func() {
+		agent.runTool("tool_1", "change_directory", json.RawMessage(`{"path":"sub"}`))
+		content, isError := agent.runTool("tool_2", "delete_lines", json.RawMessage(`{"path":"go.sum","start_line":1,"end_line":1}`)).content()
+		if !isError || !strings.Contains(content, "protected") {
+			t.Errorf("got %q, want a protected file error", content)
+		}
+	})
+
+	if got := readFile(t, "sub/go.sum"); got != "one\ntwo\n" {
+		t.Errorf("protected file changed to %q", got)
+	}
+}
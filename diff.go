@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change in a unified diff
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ' for unchanged, '-' for removed, '+' for added
+	line string
+}
+
+// unifiedDiff returns a unified diff turning a into b, or an empty string when they are identical
+func unifiedDiff(nameA, nameB, a, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+
+	changed := false
+	for start := 0; start < len(ops); {
+		// Find the next change, then extend the hunk while changes are within context of each other
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		changed = true
+
+		hunkStart := max(first-diffContext, start)
+		hunkEnd := first
+		for i := first; i < len(ops) && i <= hunkEnd+2*diffContext; i++ {
+			if ops[i].kind != ' ' {
+				hunkEnd = i
+			}
+		}
+		hunkEnd = min(hunkEnd+diffContext+1, len(ops))
+
+		writeHunk(&out, ops, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+
+	if !changed {
+		return ""
+	}
+
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []diffOp, from, to int) {
+	// Line numbers are the position of the first hunk line in each file
+	lineA, lineB := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			lineA++
+		}
+		if op.kind != '-' {
+			lineB++
+		}
+	}
+
+	countA, countB := 0, 0
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			countA++
+		}
+		if op.kind != '-' {
+			countB++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", lineA, countA, lineB, countB)
+	for _, op := range ops[from:to] {
+		fmt.Fprintf(out, "%c%s\n", op.kind, op.line)
+	}
+}
+
+// diffLines computes a line based edit script turning a into b. It uses Myers' algorithm, bisecting each region
+// at the middle of an optimal path so memory stays linear in the input, however many lines differ.
+func diffLines(a, b []string) []diffOp {
+	return appendDiff(nil, a, b)
+}
+
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	x, y := -1, -1
+	if len(midA) > 0 && len(midB) > 0 {
+		x, y = bisectDiff(midA, midB)
+	}
+	if x >= 0 {
+		ops = appendDiff(ops, midA[:x], midB[:y])
+		ops = appendDiff(ops, midA[x:], midB[y:])
+	} else {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	return ops
+}
+
+// bisectDiff searches for an optimal edit path from both ends of a and b at once, returning the point where the
+// two searches meet, or -1, -1 when a and b have no line in common. vf and vb hold the furthest x reached on each
+// diagonal going forwards and, measured from the end, going backwards.
+func bisectDiff(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	vf := make([]int, 2*maxD+2)
+	vb := make([]int, 2*maxD+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	delta := n - m
+	// With an odd delta the paths meet during a forward step, with an even one during a backward step
+	front := delta%2 != 0
+	// Diagonals that have run off the edge of the grid are trimmed from later steps
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || k != d && vf[i-1] < vf[i+1] {
+				x = vf[i+1]
+			} else {
+				x = vf[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			vf[i] = x
+
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case front:
+				j := offset + delta - k
+				if j >= 0 && j < len(vb) && vb[j] != -1 && x >= n-vb[j] {
+					return x, y
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || k != d && vb[i-1] < vb[i+1] {
+				x = vb[i+1]
+			} else {
+				x = vb[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x, y = x+1, y+1
+			}
+			vb[i] = x
+
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !front:
+				j := offset + delta - k
+				if j >= 0 && j < len(vf) && vf[j] != -1 {
+					fx := vf[j]
+					fy := fx - (j - offset)
+					if fx >= n-x {
+						return fx, fy
+					}
+				}
+			}
+		}
+	}
+
+	return -1, -1
+}
+
+// noNewlineMarker is what a unified diff prints after a last line that has no line terminator
+const noNewlineMarker = "\\ No newline at end of file"
+
+// splitLines splits text into lines without their terminators. A final line lacking one carries the marker on a
+// line of its own, so it differs from the same line with a terminator and the marker is printed after it.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if !strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += "\n" + noNewlineMarker
+	}
+
+	return lines
+}
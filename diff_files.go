@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+var DiffFilesDefinition = ToolDefinition{
+	Name:        "diff_files",
+	Description: "Compare two files and return a unified diff of their differences. Use this to compare an implementation against an expected output or a backup.",
+	InputSchema: DiffFilesInputSchema,
+	Function:    DiffFiles,
+}
+
+type DiffFilesInput struct {
+	PathA string `json:"path_a" jsonschema_description:"The relative path of the original file."`
+	PathB string `json:"path_b" jsonschema_description:"The relative path of the file to compare against the original."`
+}
+
+var DiffFilesInputSchema = GenerateSchema[DiffFilesInput]()
+
+func DiffFiles(input json.RawMessage) (string, error) {
+	diffFilesInput := DiffFilesInput{}
+	err := json.Unmarshal(input, &diffFilesInput)
+	if err != nil {
+		return "", err
+	}
+
+	if diffFilesInput.PathA == "" || diffFilesInput.PathB == "" {
+		return "", fmt.Errorf("invalid input parameters: %w", ErrInvalidInput)
+	}
+
+	contentA, err := readSandboxedFile(diffFilesInput.PathA)
+	if err != nil {
+		return "", err
+	}
+	contentB, err := readSandboxedFile(diffFilesInput.PathB)
+	if err != nil {
+		return "", err
+	}
+
+	diff := unifiedDiff(diffFilesInput.PathA, diffFilesInput.PathB, contentA, contentB)
+	if diff == "" {
+		return "files are identical", nil
+	}
+
+	return diff, nil
+}
+
+func readSandboxedFile(filePath string) (string, error) {
+	resolved, err := resolvePath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkRegularFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkFileSize(resolved, false)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDiffFilesIdentical(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "a.txt", "one\ntwo\n")
+	writeFile(t, "b.txt", "one\ntwo\n")
+
+	got, err := callTool(t, DiffFiles, map[string]any{"path_a": "a.txt", "path_b": "b.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "files are identical" {
+		t.Errorf("got %q, want files are identical", got)
+	}
+}
+
+func TestDiffFilesChangedLines(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "a.txt", "one\ntwo\nthree\nfour\n")
+	writeFile(t, "b.txt", "one\n2\nthree\nfour\nfive\n")
+
+	got, err := callTool(t, DiffFiles, map[string]any{"path_a": "a.txt", "path_b": "b.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a.txt\n+++ b.txt\n@@ -1,4 +1,5 @@\n one\n-two\n+2\n three\n four\n+five\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffFilesMissingNewline(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "a.txt", "a\n")
+	writeFile(t, "b.txt", "a")
+
+	got, err := callTool(t, DiffFiles, map[string]any{"path_a": "a.txt", "path_b": "b.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a.txt\n+++ b.txt\n@@ -1,1 +1,1 @@\n-a\n+a\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffFilesTooLarge(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxFileSize = 16
+	writeFile(t, "a.txt", strings.Repeat("x\n", 100))
+	writeFile(t, "b.txt", "x\n")
+
+	_, err := callTool(t, DiffFiles, map[string]any{"path_a": "a.txt", "path_b": "b.txt"})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("got error %v, want ErrFileTooLarge", err)
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	// Entirely different inputs are the worst case for the edit script search
+	a := make([]string, 20000)
+	b := make([]string, 20000)
+	for i := range a {
+		a[i] = fmt.Sprintf("a%d", i)
+		b[i] = fmt.Sprintf("b%d", i)
+	}
+
+	ops := diffLines(a, b)
+	if len(ops) != len(a)+len(b) {
+		t.Errorf("got %d ops, want %d", len(ops), len(a)+len(b))
+	}
+	checkDiffOps(t, a, b, ops)
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(4)))
+		}
+		return lines
+	}
+
+	for range 500 {
+		a, b := randomLines(), randomLines()
+		ops := diffLines(a, b)
+		checkDiffOps(t, a, b, ops)
+
+		unchanged := 0
+		for _, op := range ops {
+			if op.kind == ' ' {
+				unchanged++
+			}
+		}
+		if want := longestCommonSubsequence(a, b); unchanged != want {
+			t.Errorf("diff of %q and %q keeps %d lines, want %d", a, b, unchanged, want)
+		}
+	}
+}
+
+// checkDiffOps verifies that ops turn a into b
+func checkDiffOps(t *testing.T, a, b []string, ops []diffOp) {
+	t.Helper()
+
+	var gotA, gotB []string
+	for _, op := range ops {
+		if op.kind != '+' {
+			gotA = append(gotA, op.line)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.line)
+		}
+	}
+	if strings.Join(gotA, "\n") != strings.Join(a, "\n") || strings.Join(gotB, "\n") != strings.Join(b, "\n") {
+		t.Errorf("ops do not turn %q into %q: %v", a, b, ops)
+	}
+}
+
+func longestCommonSubsequence(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	return lengths[0][0]
+}
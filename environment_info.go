@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+var EnvironmentInfoDefinition = ToolDefinition{
+	Name:        "environment_info",
+	Description: "Describe the environment the agent is running in: Go version, operating system, CPU architecture, working directory and whether it is inside a git repository. Use this to tailor commands and code to the platform.",
+	InputSchema: EnvironmentInfoInputSchema,
+	Function:    EnvironmentInfo,
+}
+
+type EnvironmentInfoInput struct{}
+
+var EnvironmentInfoInputSchema = GenerateSchema[EnvironmentInfoInput]()
+
+type environmentInfo struct {
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	WorkingDir string `json:"working_dir"`
+	GitRepo    bool   `json:"git_repo"`
+}
+
+func EnvironmentInfo(input json.RawMessage) (string, error) {
+	environmentInfoInput := EnvironmentInfoInput{}
+	err := json.Unmarshal(input, &environmentInfoInput)
+	if err != nil {
+		return "", err
+	}
+
+	workingDir, err := resolvePath(".")
+	if err != nil {
+		return "", err
+	}
+
+	info := environmentInfo{
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		WorkingDir: workingDir,
+		GitRepo:    isGitRepo(workingDir),
+	}
+
+	result, err := json.Marshal(info)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// isGitRepo reports whether dir or any of its parents contains a .git directory or file
+func isGitRepo(dir string) bool {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEnvironmentInfo(t *testing.T) {
+	dir := setupWorkspace(t)
+	if err := os.Mkdir(".git", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := callTool(t, EnvironmentInfo, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info environmentInfo
+	if err := json.Unmarshal([]byte(got), &info); err != nil {
+		t.Fatal(err)
+	}
+
+	realDir, _ := filepath.EvalSymlinks(dir)
+	realWorkingDir, _ := filepath.EvalSymlinks(info.WorkingDir)
+	if info.GoVersion != runtime.Version() || info.OS != runtime.GOOS || info.Arch != runtime.GOARCH {
+		t.Errorf("got %+v, want the runtime's version and platform", info)
+	}
+	if realWorkingDir != realDir {
+		t.Errorf("working_dir is %s, want %s", info.WorkingDir, dir)
+	}
+	if !info.GitRepo {
+		t.Error("git_repo is false inside a repository")
+	}
+}
+
+func TestIsGitRepo(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if isGitRepo(nested) {
+		t.Skip("the temporary directory is itself inside a git repository")
+	}
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if !isGitRepo(nested) {
+		t.Error("a parent .git directory was not found")
+	}
+}
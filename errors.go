@@ -0,0 +1,19 @@
+package main
+
+import "errors"
+
+// Sentinel errors returned (wrapped) by tools so callers can tell failures apart with errors.Is
+var (
+	ErrInvalidInput     = errors.New("invalid input")
+	ErrInvalidJSON      = errors.New("invalid JSON")
+	ErrNotFound         = errors.New("not found")
+	ErrOutsideWorkspace = errors.New("outside the workspace")
+	ErrMultipleMatches  = errors.New("multiple matches")
+	ErrFileTooLarge     = errors.New("file too large")
+	ErrBinaryFile       = errors.New("binary file")
+	ErrTimeout          = errors.New("timed out")
+	ErrToolTurnLimit    = errors.New("tool turn limit reached")
+	ErrCommandsDisabled = errors.New("command execution is disabled; restart the agent with --allow-commands to enable it")
+	ErrExecDirDenied    = errors.New("directory not allowed for commands")
+	ErrConflict         = errors.New("conflict")
+)
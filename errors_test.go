@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestToolErrorSentinels(t *testing.T) {
+	tests := []struct {
+		name     string
+		function func(t *testing.T) error
+		want     error
+	}{
+		{
+			name: "edit_file old_str missing",
+			function: func(t *testing.T) error {
+				_, err := callTool(t, EditFile, map[string]any{"path": "file.txt", "old_str": "absent", "new_str": "x"})
+				return err
+			},
+			want: ErrNotFound,
+		},
+		{
+			name: "edit_file old_str ambiguous",
+			function: func(t *testing.T) error {
+				_, err := callTool(t, EditFile, map[string]any{"path": "file.txt", "old_str": "line", "new_str": "x"})
+				return err
+			},
+			want: ErrMultipleMatches,
+		},
+		{
+			name: "read_file outside workspace",
+			function: func(t *testing.T) error {
+				_, err := callTool(t, ReadFile, map[string]any{"path": "../outside.txt"})
+				return err
+			},
+			want: ErrOutsideWorkspace,
+		},
+		{
+			name: "edit_file missing path",
+			function: func(t *testing.T) error {
+				_, err := callTool(t, EditFile, map[string]any{"old_str": "a", "new_str": "b"})
+				return err
+			},
+			want: ErrInvalidInput,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "file.txt", "line one\nline two\n")
+
+			if err := test.function(t); !errors.Is(err, test.want) {
+				t.Errorf("got error %v, want %v", err, test.want)
+			}
+		})
+	}
+}
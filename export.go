@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Conversation export formats selectable with --export
+const (
+	ExportOpenAI = "openai"
+)
+
+// openAIMessage is a message in the OpenAI chat completions format
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+type openAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// exportFlag parses a format=path export destination into cfg
+func exportFlag(cfg *Config) func(string) error {
+	return func(value string) error {
+		format, filePath, ok := strings.Cut(value, "=")
+		if !ok || filePath == "" {
+			return fmt.Errorf("expected format=path, got %q", value)
+		}
+		if format != ExportOpenAI {
+			return fmt.Errorf("unknown export format %q, expected %s", format, ExportOpenAI)
+		}
+
+		cfg.ExportFormat = format
+		cfg.ExportPath = filePath
+		return nil
+	}
+}
+
+// writeOpenAIExport saves the conversation to filePath as an OpenAI chat completions messages array
+func writeOpenAIExport(filePath, system string, conversation []anthropic.MessageParam) error {
+	data, err := json.MarshalIndent(toOpenAIMessages(system, conversation), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, append(data, '\n'), 0644)
+}
+
+// toOpenAIMessages converts the conversation to the OpenAI chat completions message shape. The mapping is lossy:
+//   - thinking blocks are dropped as the format has nowhere to put them
+//   - a tool result's error flag is dropped, only its text survives
+//   - tool results become separate tool role messages placed before any text sent alongside them
+//   - several text blocks in one message are joined with blank lines
+func toOpenAIMessages(system string, conversation []anthropic.MessageParam) []openAIMessage {
+	messages := []openAIMessage{}
+	if system != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: &system})
+	}
+
+	for _, message := range conversation {
+		var texts []string
+		var toolCalls []openAIToolCall
+		for _, block := range message.Content {
+			switch {
+			case block.OfText != nil:
+				texts = append(texts, block.OfText.Text)
+			case block.OfToolUse != nil:
+				arguments, _ := json.Marshal(block.OfToolUse.Input)
+				toolCalls = append(toolCalls, openAIToolCall{
+					ID:       block.OfToolUse.ID,
+					Type:     "function",
+					Function: openAIFunctionCall{Name: block.OfToolUse.Name, Arguments: redactSecrets(string(arguments))},
+				})
+			case block.OfToolResult != nil:
+				content := redactSecrets(toolResultText(block.OfToolResult))
+				messages = append(messages, openAIMessage{Role: "tool", Content: &content, ToolCallID: block.OfToolResult.ToolUseID})
+			}
+		}
+
+		if len(texts) == 0 && len(toolCalls) == 0 {
+			continue
+		}
+
+		exported := openAIMessage{Role: string(message.Role), ToolCalls: toolCalls}
+		// An assistant message that only calls tools has null content
+		if len(texts) > 0 {
+			content := strings.Join(texts, "\n\n")
+			exported.Content = &content
+		}
+		messages = append(messages, exported)
+	}
+
+	return messages
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAIExport(t *testing.T) {
+	setupWorkspace(t)
+	config.ExportFormat = ExportOpenAI
+	config.ExportPath = "export.json"
+	writeFile(t, "greeting.txt", "hello")
+
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "It says hello", nil))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+	agent.system = "Be brief."
+	captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "Read greeting.txt"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	var exported []map[string]any
+	if err := json.Unmarshal([]byte(readFile(t, "export.json")), &exported); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []map[string]any{
+		{"role": "system", "content": "Be brief."},
+		{"role": "user", "content": "Read greeting.txt"},
+		{"role": "assistant", "content": nil, "tool_calls": []any{map[string]any{
+			"id":       "tool_1",
+			"type":     "function",
+			"function": map[string]any{"name": "read_file", "arguments": `{"path":"greeting.txt"}`},
+		}}},
+		{"role": "tool", "content": "hello", "tool_call_id": "tool_1"},
+		{"role": "assistant", "content": "It says hello"},
+	}
+	if !reflect.DeepEqual(exported, want) {
+		got, _ := json.MarshalIndent(exported, "", "  ")
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestExportFlag(t *testing.T) {
+	cfg, err := ParseFlags([]string{"--export", "openai=out.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ExportFormat != ExportOpenAI || cfg.ExportPath != "out.json" {
+		t.Errorf("got format %q path %q", cfg.ExportFormat, cfg.ExportPath)
+	}
+
+	for _, value := range []string{"openai", "csv=out.csv", "openai="} {
+		if _, err := ParseFlags([]string{"--export", value}); err == nil {
+			t.Errorf("--export %s was accepted", value)
+		}
+	}
+}
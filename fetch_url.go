@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	fetchTimeout  = 30 * time.Second
+	fetchMaxBytes = 1 << 20
+)
+
+var FetchURLDefinition = ToolDefinition{
+	Name:        "fetch_url",
+	Description: "Fetch a web resource over HTTP(S) with a GET request and return its status and body as text. Use this to read documentation or raw files referenced in a task. Only text content types are supported and large bodies are truncated.",
+	InputSchema: FetchURLInputSchema,
+	Function:    FetchURL,
+	Timeout:     2 * fetchTimeout,
+}
+
+type FetchURLInput struct {
+	URL string `json:"url" jsonschema_description:"The absolute http or https URL to fetch."`
+}
+
+var FetchURLInputSchema = GenerateSchema[FetchURLInput]()
+
+var errPrivateAddress = errors.New("requests to private or local addresses are blocked; restart the agent with --allow-private-urls to allow them")
+
+func FetchURL(input json.RawMessage) (string, error) {
+	fetchURLInput := FetchURLInput{}
+	err := json.Unmarshal(input, &fetchURLInput)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := url.Parse(fetchURLInput.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "", fmt.Errorf("url must be an absolute http or https URL: %w", ErrInvalidInput)
+	}
+
+	resp, err := newFetchClient(config.AllowPrivateURLs).Get(target.String())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if !isTextContentType(contentType) {
+		return "", fmt.Errorf("unsupported content type %q: only text content can be fetched", contentType)
+	}
+
+	// Read one byte past the cap to tell a body of exactly the cap apart from a longer one
+	body, err := io.ReadAll(io.LimitReader(resp.Body, fetchMaxBytes+1))
+	if err != nil {
+		return "", err
+	}
+
+	truncated := ""
+	if len(body) > fetchMaxBytes {
+		body = body[:fetchMaxBytes]
+		truncated = fmt.Sprintf("\n\n[truncated at %d bytes]", fetchMaxBytes)
+	}
+
+	return fmt.Sprintf("Status: %s\n\n%s%s", resp.Status, body, truncated), nil
+}
+
+// newFetchClient creates an HTTP client that, unless allowPrivate is set, refuses to connect to private addresses.
+// The check runs on the resolved address at dial time so redirects and DNS tricks can't get around it.
+func newFetchClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isPrivateIP(ip) {
+				return fmt.Errorf("%s: %w", host, errPrivateAddress)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout:   fetchTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+	}
+}
+
+// isPrivateIP reports whether ip is loopback, private, link-local or unspecified
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
+
+// isTextContentType reports whether a Content-Type header describes text that can be returned to Claude
+func isTextContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-yaml", "application/yaml":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newFetchServer(t *testing.T, contentType, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestFetchURL(t *testing.T) {
+	setupWorkspace(t)
+	config.AllowPrivateURLs = true
+	server := newFetchServer(t, "text/plain; charset=utf-8", "some docs")
+
+	got, err := callTool(t, FetchURL, map[string]any{"url": server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Status: 200 OK\n\nsome docs" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFetchURLSizeCap(t *testing.T) {
+	setupWorkspace(t)
+	config.AllowPrivateURLs = true
+	server := newFetchServer(t, "text/plain", strings.Repeat("x", fetchMaxBytes+100))
+
+	got, err := callTool(t, FetchURL, map[string]any{"url": server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(got, "[truncated at 1048576 bytes]") {
+		t.Errorf("body was not truncated, ends with %q", got[len(got)-40:])
+	}
+	if strings.Count(got, "x") != fetchMaxBytes {
+		t.Errorf("got %d bytes of body, want %d", strings.Count(got, "x"), fetchMaxBytes)
+	}
+}
+
+func TestFetchURLRejectsBinary(t *testing.T) {
+	setupWorkspace(t)
+	config.AllowPrivateURLs = true
+	server := newFetchServer(t, "application/octet-stream", "\x00\x01")
+
+	if _, err := callTool(t, FetchURL, map[string]any{"url": server.URL}); err == nil {
+		t.Error("binary content was returned")
+	}
+}
+
+func TestFetchURLBlocksPrivateAddress(t *testing.T) {
+	setupWorkspace(t)
+	server := newFetchServer(t, "text/plain", "internal")
+
+	_, err := callTool(t, FetchURL, map[string]any{"url": server.URL})
+	if !errors.Is(err, errPrivateAddress) {
+		t.Errorf("got error %v, want errPrivateAddress", err)
+	}
+}
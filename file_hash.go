@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+var FileHashDefinition = ToolDefinition{
+	Name:        "file_hash",
+	Description: "Return the SHA-256 hash and size in bytes of a file as JSON, without reading it into the conversation. Use this to check whether a file has changed since it was last read.",
+	InputSchema: FileHashInputSchema,
+	Function:    FileHash,
+	Retries:     readToolRetries,
+}
+
+type FileHashInput struct {
+	Path string `json:"path" jsonschema_description:"The relative path of the file to hash."`
+}
+
+var FileHashInputSchema = GenerateSchema[FileHashInput]()
+
+type fileHash struct {
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
+}
+
+func FileHash(input json.RawMessage) (string, error) {
+	fileHashInput := FileHashInput{}
+	err := json.Unmarshal(input, &fileHashInput)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := resolvePath(fileHashInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkRegularFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	sum, size, err := hashFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(fileHash{SHA256: sum, Bytes: size})
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// hashFile streams a file through SHA-256, returning the hex digest and the number of bytes read
+func hashFile(filePath string) (string, int64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFileHash(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "hello.txt", "hello world")
+	writeFile(t, "empty.txt", "")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"hello.txt", `{"sha256":"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9","bytes":11}`},
+		{"empty.txt", `{"sha256":"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855","bytes":0}`},
+	}
+
+	for _, test := range tests {
+		got, err := callTool(t, FileHash, map[string]any{"path": test.path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.path, got, test.want)
+		}
+	}
+}
+
+func TestFileHashRejections(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "dir/file.txt", "x")
+
+	if _, err := callTool(t, FileHash, map[string]any{"path": "../outside.txt"}); !errors.Is(err, ErrOutsideWorkspace) {
+		t.Errorf("outside the workspace: got error %v, want ErrOutsideWorkspace", err)
+	}
+	if _, err := callTool(t, FileHash, map[string]any{"path": "dir"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("directory: got error %v, want ErrInvalidInput", err)
+	}
+}
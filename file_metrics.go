@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var FileMetricsDefinition = ToolDefinition{
+	Name:        "file_metrics",
+	Description: "Count the lines, words and bytes in a file without reading it into the conversation, plus the number of functions for Go files. Use this to size up a file before deciding how to read it.",
+	InputSchema: FileMetricsInputSchema,
+	Function:    FileMetrics,
+	Retries:     readToolRetries,
+}
+
+type FileMetricsInput struct {
+	Path       string `json:"path" jsonschema_description:"The relative path of the file to measure."`
+	AllowLarge bool   `json:"allow_large,omitempty" jsonschema_description:"Measure the file even if it exceeds the maximum file size."`
+}
+
+var FileMetricsInputSchema = GenerateSchema[FileMetricsInput]()
+
+type fileMetrics struct {
+	Lines     int  `json:"lines"`
+	Words     int  `json:"words"`
+	Bytes     int  `json:"bytes"`
+	Functions *int `json:"functions,omitempty"`
+}
+
+func FileMetrics(input json.RawMessage) (string, error) {
+	fileMetricsInput := FileMetricsInput{}
+	err := json.Unmarshal(input, &fileMetricsInput)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := resolvePath(fileMetricsInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkRegularFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkFileSize(resolved, fileMetricsInput.AllowLarge)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(resolved)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	isGo := filepath.Ext(resolved) == ".go"
+	metrics := fileMetrics{}
+	functions := 0
+
+	// Stream line by line so only a single line is held in memory at a time
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			metrics.Lines++
+			metrics.Bytes += len(line)
+			metrics.Words += len(bytes.Fields(line))
+			if isGo && bytes.HasPrefix(line, []byte("func ")) {
+				functions++
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if isGo {
+		metrics.Functions = &functions
+	}
+
+	result, err := json.Marshal(metrics)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFileMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "notes.txt", content: "one two\nthree\n\nfour five six", want: `{"lines":4,"words":6,"bytes":28}`},
+		{name: "main.go", content: "package main\n\nfunc a() {}\n\nfunc (t T) b() {}\n", want: `{"lines":5,"words":10,"bytes":45,"functions":2}`},
+		{name: "empty.txt", content: "", want: `{"lines":0,"words":0,"bytes":0}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, test.name, test.content)
+
+			got, err := callTool(t, FileMetrics, map[string]any{"path": test.name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFileMetricsSizeGuard(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxFileSize = 4
+	writeFile(t, "large.txt", "more than four bytes\n")
+
+	_, err := callTool(t, FileMetrics, map[string]any{"path": "large.txt"})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("got error %v, want ErrFileTooLarge", err)
+	}
+
+	if _, err := callTool(t, FileMetrics, map[string]any{"path": "large.txt", "allow_large": true}); err != nil {
+		t.Errorf("allow_large: %v", err)
+	}
+}
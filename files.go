@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+)
+
+// binarySniffLength is how much of a file is inspected when deciding whether it is binary
+const binarySniffLength = 8000
+
+// writeFileAtomic writes data to a temporary file alongside filePath and renames it into place,
+// so readers never observe a partially written file
+func writeFileAtomic(filePath string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpName, filePath)
+}
+
+// backupFile copies an existing file to <path>.bak before it is modified when --backup is enabled.
+// Files that don't exist yet have nothing to back up.
+func backupFile(filePath string) error {
+	if !config.Backup {
+		return nil
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filePath+".bak", content, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("failed to back up %s: %w", filePath, err)
+	}
+
+	return nil
+}
+
+// checkFileSize returns an error when filePath is larger than the configured maximum file size,
+// unless allowLarge is set
+func checkFileSize(filePath string, allowLarge bool) error {
+	if allowLarge || config.MaxFileSize <= 0 {
+		return nil
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	if info.Size() > config.MaxFileSize {
+		return fmt.Errorf("file %s is %d bytes which exceeds the %d byte limit; read a smaller line range instead, or set allow_large to read it anyway: %w", filePath, info.Size(), config.MaxFileSize, ErrFileTooLarge)
+	}
+
+	return nil
+}
+
+// checkRegularFile refuses directories, fifos, devices and sockets, as reading a fifo or device can block forever
+func checkRegularFile(filePath string) error {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory, use list_files to see its contents: %w", filePath, ErrInvalidInput)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is %s rather than a regular file and can't be read: %w", filePath, specialFileKind(info.Mode()), ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// specialFileKind describes the type of a non-regular file for error messages
+func specialFileKind(mode os.FileMode) string {
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return "a named pipe"
+	case mode&os.ModeSocket != 0:
+		return "a socket"
+	case mode&os.ModeDevice != 0:
+		return "a device"
+	default:
+		return "a special file"
+	}
+}
+
+// readTextFile reads a file for returning to Claude, refusing special files, files over the size limit and
+// binary files
+func readTextFile(filePath string, allowLarge bool) (string, error) {
+	err := checkRegularFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkFileSize(filePath, allowLarge)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if isBinary(content) {
+		return "", fmt.Errorf("file %s appears to be binary: %w", filePath, ErrBinaryFile)
+	}
+
+	return string(content), nil
+}
+
+// isBinary reports whether content looks like binary data, using a NUL byte in its leading bytes as the signal
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binarySniffLength)], 0) != -1
+}
+
+// autoFormatNote is appended to a tool result when --autofmt reformatted what the tool wrote
+const autoFormatNote = " (auto-formatted with gofmt)"
+
+// autoFormat gofmts content destined for a .go file when --autofmt is set, returning the content to write and
+// the note to add to the tool result. Content that doesn't parse is left alone for Claude to fix.
+func autoFormat(filePath string, content []byte) ([]byte, string) {
+	if !config.AutoFormat || filepath.Ext(filePath) != ".go" {
+		return content, ""
+	}
+
+	formatted, err := format.Source(content)
+	if err != nil || bytes.Equal(formatted, content) {
+		return content, ""
+	}
+
+	return formatted, autoFormatNote
+}
+
+// autoFormatFile gofmts a file already written by something else, such as gopls, reporting whether it changed
+func autoFormatFile(filePath string) (bool, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return false, err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, err
+	}
+
+	formatted, note := autoFormat(filePath, content)
+	if note == "" {
+		return false, nil
+	}
+
+	return true, writeFileAtomic(filePath, formatted, info.Mode().Perm())
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackupBeforeEdit(t *testing.T) {
+	setupWorkspace(t)
+	config.Backup = true
+	writeFile(t, "file.txt", "original\n")
+
+	_, err := callTool(t, EditFile, map[string]any{"path": "file.txt", "old_str": "original", "new_str": "edited"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, "file.txt.bak"); got != "original\n" {
+		t.Errorf("backup has %q, want the pre-edit content", got)
+	}
+	if got := readFile(t, "file.txt"); got != "edited\n" {
+		t.Errorf("file has %q", got)
+	}
+}
+
+func TestBackupSkipsNewFiles(t *testing.T) {
+	setupWorkspace(t)
+	config.Backup = true
+
+	_, err := callTool(t, EditFile, map[string]any{"path": "new.txt", "old_str": "", "new_str": "content\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat("new.txt.bak"); !os.IsNotExist(err) {
+		t.Errorf("a new file was backed up: %v", err)
+	}
+}
+
+func TestAutoFormatGoEdit(t *testing.T) {
+	setupWorkspace(t)
+	config.AutoFormat = true
+	writeFile(t, "main.go", "package main\n\nfunc main() {\n  x := 1\n      _ = x\n}\n")
+
+	got, err := callTool(t, EditFile, map[string]any{"path": "main.go", "old_str": "x := 1", "new_str": "x := 2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "OK"+autoFormatNote {
+		t.Errorf("got %q, want the auto-format note", got)
+	}
+	if content := readFile(t, "main.go"); content != "package main\n\nfunc main() {\n\tx := 2\n\t_ = x\n}\n" {
+		t.Errorf("file was not formatted: %q", content)
+	}
+}
+
+func TestAutoFormatSkips(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoFormat bool
+		file       string
+		content    string
+	}{
+		{name: "disabled", file: "main.go", content: "package main\n\nfunc main() {\n  x := 1\n}\n"},
+		{name: "not go", autoFormat: true, file: "notes.txt", content: "func main() {\n  x := 1\n}\n"},
+		{name: "does not parse", autoFormat: true, file: "main.go", content: "package main\n\nfunc main() {\n  x := 1\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			config.AutoFormat = test.autoFormat
+
+			formatted, note := autoFormat(test.file, []byte(test.content))
+			if string(formatted) != test.content || note != "" {
+				t.Errorf("got %q with note %q, want the content unchanged", formatted, note)
+			}
+		})
+	}
+}
+
+func TestReadFileRefusesSpecialFiles(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "regular.txt", "plain")
+	writeFile(t, "dir/inner.txt", "inner")
+
+	if got, err := callTool(t, ReadFile, map[string]any{"path": "regular.txt"}); err != nil || got != "plain" {
+		t.Errorf("regular file: got %q, %v", got, err)
+	}
+	if _, err := callTool(t, ReadFile, map[string]any{"path": "dir"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("directory: got error %v, want ErrInvalidInput", err)
+	}
+
+	if err := exec.Command("mkfifo", "pipe.go").Run(); err != nil {
+		t.Skipf("fifos aren't supported here: %v", err)
+	}
+	writeFile(t, "other.go", "package main\n")
+
+	// Every tool that reads a file must refuse the fifo rather than block opening it
+	text := func(tool func(json.RawMessage) (string, error)) func(json.RawMessage) error {
+		return func(input json.RawMessage) error {
+			_, err := tool(input)
+			return err
+		}
+	}
+	tools := []struct {
+		name  string
+		call  func(json.RawMessage) error
+		input string
+	}{
+		{"read_file", text(ReadFile), `{"path":"pipe.go"}`},
+		{"diff_files", text(DiffFiles), `{"path_a":"other.go","path_b":"pipe.go"}`},
+		{"check_syntax", func(input json.RawMessage) error {
+			_, err := CheckSyntax(input)
+			return err
+		}, `{"path":"pipe.go"}`},
+		{"head_file", text(HeadFile), `{"path":"pipe.go"}`},
+		{"tail_file", text(TailFile), `{"path":"pipe.go"}`},
+		{"file_metrics", text(FileMetrics), `{"path":"pipe.go"}`},
+		{"list_symbols", text(ListSymbols), `{"path":"pipe.go"}`},
+		{"read_function", text(ReadFunction), `{"path":"pipe.go","name":"main"}`},
+	}
+
+	for _, tool := range tools {
+		done := make(chan error, 1)
+		go func() {
+			done <- tool.call(json.RawMessage(tool.input))
+		}()
+		select {
+		case err := <-done:
+			if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), "named pipe") {
+				t.Errorf("%s: got error %v, want a named pipe refusal", tool.name, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s blocked reading a fifo", tool.name)
+		}
+	}
+}
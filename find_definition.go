@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+var FindDefinitionDefinition = ToolDefinition{
+	Name:        "find_definition",
+	Description: "Find where a Go symbol is declared across the workspace. Accepts a function, type, variable or constant name, or 'Type.Method' for a method. Returns every matching declaration with its file and line as JSON.",
+	InputSchema: FindDefinitionInputSchema,
+	Function:    FindDefinition,
+}
+
+type FindDefinitionInput struct {
+	Symbol string `json:"symbol" jsonschema_description:"The name of the symbol, e.g. 'NewAgent' or 'Agent.Run' for a method."`
+}
+
+var FindDefinitionInputSchema = GenerateSchema[FindDefinitionInput]()
+
+type symbolLocation struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+func FindDefinition(input json.RawMessage) (string, error) {
+	findDefinitionInput := FindDefinitionInput{}
+	err := json.Unmarshal(input, &findDefinitionInput)
+	if err != nil {
+		return "", err
+	}
+
+	symbol := strings.TrimSpace(findDefinitionInput.Symbol)
+	if symbol == "" {
+		return "", fmt.Errorf("symbol must not be empty: %w", ErrInvalidInput)
+	}
+
+	// A qualified name only matches methods on that receiver
+	receiver, name, qualified := strings.Cut(symbol, ".")
+	if !qualified {
+		name, receiver = receiver, ""
+	}
+
+	locations := []symbolLocation{}
+	err = walkGoFiles(func(relPath string, fset *token.FileSet, file *ast.File) {
+		add := func(node ast.Node, kind, name string) {
+			locations = append(locations, symbolLocation{File: relPath, Line: fset.Position(node.Pos()).Line, Kind: kind, Name: name})
+		}
+
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Name.Name != name {
+					continue
+				}
+				if d.Recv == nil && !qualified {
+					add(d, "function", name)
+				} else if d.Recv != nil && (!qualified || receiverTypeName(d) == receiver) {
+					add(d, "method", receiverTypeName(d)+"."+name)
+				}
+			case *ast.GenDecl:
+				if qualified {
+					continue
+				}
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						if s.Name.Name == name {
+							add(s, "type", name)
+						}
+					case *ast.ValueSpec:
+						for _, ident := range s.Names {
+							if ident.Name == name {
+								add(ident, strings.ToLower(d.Tok.String()), name)
+							}
+						}
+					}
+				}
+			}
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(locations) == 0 {
+		return fmt.Sprintf("%s not found", symbol), nil
+	}
+
+	result, err := json.Marshal(locations)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// setupSymbolModule writes a small multi-file module for the Go navigation tools
+func setupSymbolModule(t *testing.T) {
+	t.Helper()
+
+	setupWorkspace(t)
+	writeFile(t, "go.mod", "module example.com/tmp\n\ngo 1.21\n")
+	writeFile(t, "main.go", "package main\n\nfunc main() {\n\tserver := NewServer()\n\tserver.Run()\n}\n")
+	writeFile(t, "server.go", "package main\n\ntype Server struct{}\n\nfunc NewServer() *Server {\n\treturn &Server{}\n}\n\nfunc (s *Server) Run() {}\n")
+	writeFile(t, "worker/worker.go", "package worker\n\ntype Worker struct{}\n\n// NewServer in a comment is not a reference\nfunc (w Worker) Run() {}\n\nconst Limit = 3\n")
+}
+
+func TestFindDefinition(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   []symbolLocation
+	}{
+		{symbol: "NewServer", want: []symbolLocation{{File: "server.go", Line: 5, Kind: "function", Name: "NewServer"}}},
+		{symbol: "Server", want: []symbolLocation{{File: "server.go", Line: 3, Kind: "type", Name: "Server"}}},
+		{symbol: "Limit", want: []symbolLocation{{File: "worker/worker.go", Line: 8, Kind: "const", Name: "Limit"}}},
+		{symbol: "Run", want: []symbolLocation{
+			{File: "server.go", Line: 9, Kind: "method", Name: "Server.Run"},
+			{File: "worker/worker.go", Line: 6, Kind: "method", Name: "Worker.Run"},
+		}},
+		{symbol: "Worker.Run", want: []symbolLocation{{File: "worker/worker.go", Line: 6, Kind: "method", Name: "Worker.Run"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.symbol, func(t *testing.T) {
+			setupSymbolModule(t)
+
+			got, err := callTool(t, FindDefinition, map[string]any{"symbol": test.symbol})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var locations []symbolLocation
+			if err := json.Unmarshal([]byte(got), &locations); err != nil {
+				t.Fatalf("%v: %s", err, got)
+			}
+			if !slices.Equal(locations, test.want) {
+				t.Errorf("got %+v, want %+v", locations, test.want)
+			}
+		})
+	}
+}
+
+func TestFindDefinitionNotFound(t *testing.T) {
+	setupSymbolModule(t)
+
+	got, err := callTool(t, FindDefinition, map[string]any{"symbol": "Missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Missing not found" {
+		t.Errorf("got %q", got)
+	}
+}
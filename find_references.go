@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+var FindReferencesDefinition = ToolDefinition{
+	Name:        "find_references",
+	Description: "Find every place a Go identifier is used across the workspace before changing it. Matching is by name on the syntax tree, so it skips comments and strings but is approximate: unrelated identifiers with the same name in other packages or scopes are included. Declarations are excluded; use find_definition for those.",
+	InputSchema: FindReferencesInputSchema,
+	Function:    FindReferences,
+}
+
+type FindReferencesInput struct {
+	Name string `json:"name" jsonschema_description:"The identifier to find references to, e.g. 'NewAgent'. For methods and fields give just the name, not Type.Name."`
+}
+
+var FindReferencesInputSchema = GenerateSchema[FindReferencesInput]()
+
+type referenceLocation struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func FindReferences(input json.RawMessage) (string, error) {
+	findReferencesInput := FindReferencesInput{}
+	err := json.Unmarshal(input, &findReferencesInput)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(findReferencesInput.Name)
+	if !token.IsIdentifier(name) {
+		return "", fmt.Errorf("name must be a Go identifier: %w", ErrInvalidInput)
+	}
+
+	locations := []referenceLocation{}
+	err = walkGoFiles(func(relPath string, fset *token.FileSet, file *ast.File) {
+		declarations := declarationIdents(file)
+		ast.Inspect(file, func(node ast.Node) bool {
+			ident, ok := node.(*ast.Ident)
+			if !ok || ident.Name != name || declarations[ident] {
+				return true
+			}
+
+			position := fset.Position(ident.Pos())
+			locations = append(locations, referenceLocation{File: relPath, Line: position.Line, Column: position.Column})
+			return true
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(locations) == 0 {
+		return fmt.Sprintf("no references to %s found", name), nil
+	}
+
+	result, err := json.Marshal(locations)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// declarationIdents collects the identifiers that name a top-level function, method, type, variable or constant
+func declarationIdents(file *ast.File) map[*ast.Ident]bool {
+	declarations := map[*ast.Ident]bool{}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			declarations[d.Name] = true
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					declarations[s.Name] = true
+				case *ast.ValueSpec:
+					for _, ident := range s.Names {
+						declarations[ident] = true
+					}
+				}
+			}
+		}
+	}
+
+	return declarations
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFindReferences(t *testing.T) {
+	setupSymbolModule(t)
+	writeFile(t, "client.go", "package main\n\nvar defaultServer = NewServer()\n")
+
+	got, err := callTool(t, FindReferences, map[string]any{"name": "NewServer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var locations []referenceLocation
+	if err := json.Unmarshal([]byte(got), &locations); err != nil {
+		t.Fatalf("%v: %s", err, got)
+	}
+	// The declaration in server.go and the mention in a comment are not references
+	want := []referenceLocation{
+		{File: "client.go", Line: 3, Column: 21},
+		{File: "main.go", Line: 4, Column: 12},
+	}
+	if !slices.Equal(locations, want) {
+		t.Errorf("got %+v, want %+v", locations, want)
+	}
+}
+
+func TestFindReferencesNone(t *testing.T) {
+	setupSymbolModule(t)
+
+	got, err := callTool(t, FindReferences, map[string]any{"name": "Limit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "no references to Limit found" {
+		t.Errorf("got %q", got)
+	}
+
+	_, err = callTool(t, FindReferences, map[string]any{"name": "not an identifier"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("got error %v, want ErrInvalidInput", err)
+	}
+}
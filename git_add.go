@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var GitAddDefinition = ToolDefinition{
+	Name:        "git_add",
+	Description: "Stage exactly the given files in git with 'git add', leaving everything else as it is, so a commit contains only related changes. Returns the updated 'git status --short'.",
+	InputSchema: GitAddInputSchema,
+	Function:    GitAdd,
+	Timeout:     commandToolTimeout,
+}
+
+type GitAddInput struct {
+	Paths []string `json:"paths" jsonschema_description:"The relative paths of the files to stage."`
+}
+
+var GitAddInputSchema = GenerateSchema[GitAddInput]()
+
+func GitAdd(input json.RawMessage) (string, error) {
+	gitAddInput := GitAddInput{}
+	err := json.Unmarshal(input, &gitAddInput)
+	if err != nil {
+		return "", err
+	}
+
+	if len(gitAddInput.Paths) == 0 {
+		return "", fmt.Errorf("paths must not be empty: %w", ErrInvalidInput)
+	}
+
+	args := []string{"add", "--"}
+	for _, p := range gitAddInput.Paths {
+		resolved, err := resolvePath(p)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, resolved)
+	}
+
+	output, err := runCommand("git", args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("git add failed: %s", strings.TrimSpace(output))
+		}
+		return "", err
+	}
+
+	status, err := runCommand("git", "status", "--short")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(status, "\n"), nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGitAddStagesOnlyGivenPaths(t *testing.T) {
+	setupGitRepo(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	config.AllowCommands = true
+	writeFile(t, "a.txt", "a changed\n")
+	writeFile(t, "b.txt", "b changed\n")
+	writeFile(t, "c.txt", "c\n")
+
+	got, err := callTool(t, GitAdd, map[string]any{"paths": []string{"a.txt", "./c.txt"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "M  a.txt\n M b.txt\nA  c.txt"; got != want {
+		t.Errorf("got status %q, want %q", got, want)
+	}
+	staged, err := exec.Command("git", "diff", "--cached", "--name-only").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(staged)); strings.Join(got, " ") != "a.txt c.txt" {
+		t.Errorf("staged %v, want a.txt and c.txt only", got)
+	}
+}
+
+func TestGitAddRejections(t *testing.T) {
+	setupGitRepo(t, map[string]string{"a.txt": "a\n"})
+	config.AllowCommands = true
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  error
+	}{
+		{"no paths", []string{}, ErrInvalidInput},
+		{"outside the workspace", []string{"a.txt", "../elsewhere.txt"}, ErrOutsideWorkspace},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := callTool(t, GitAdd, map[string]any{"paths": test.paths}); !errors.Is(err, test.want) {
+				t.Errorf("got error %v, want %v", err, test.want)
+			}
+		})
+	}
+
+	config.AllowCommands = false
+	if _, err := callTool(t, GitAdd, map[string]any{"paths": []string{"a.txt"}}); !errors.Is(err, ErrCommandsDisabled) {
+		t.Errorf("got error %v, want ErrCommandsDisabled", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var GitBlameDefinition = ToolDefinition{
+	Name:        "git_blame",
+	Description: "Show which commit last changed each line in a range of a file, with 'git blame'. Returns one line per source line as 'commit author date line: content'. Use this to find the change that introduced a bug or the reasoning behind some code.",
+	InputSchema: GitBlameInputSchema,
+	Function:    GitBlame,
+	Timeout:     commandToolTimeout,
+}
+
+type GitBlameInput struct {
+	Path      string `json:"path" jsonschema_description:"The relative path of the file to blame."`
+	StartLine int    `json:"start_line" jsonschema_description:"The first line of the range (1-based)."`
+	EndLine   int    `json:"end_line" jsonschema_description:"The last line of the range (1-based, inclusive)."`
+}
+
+var GitBlameInputSchema = GenerateSchema[GitBlameInput]()
+
+func GitBlame(input json.RawMessage) (string, error) {
+	gitBlameInput := GitBlameInput{}
+	err := json.Unmarshal(input, &gitBlameInput)
+	if err != nil {
+		return "", err
+	}
+
+	start, end := gitBlameInput.StartLine, gitBlameInput.EndLine
+	if start < 1 || end < start {
+		return "", fmt.Errorf("invalid line range %d-%d: %w", start, end, ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(gitBlameInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runCommand("git", "blame", "--line-porcelain", "-L", fmt.Sprintf("%d,%d", start, end), "--", resolved)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", err
+		}
+		message := strings.TrimSpace(output)
+		if strings.Contains(message, "not a git repository") {
+			return "", fmt.Errorf("%s is not in a git repository: %w", gitBlameInput.Path, ErrNotFound)
+		}
+		return "", fmt.Errorf("git blame failed: %s", message)
+	}
+
+	return strings.Join(parseBlame(output), "\n"), nil
+}
+
+// parseBlame condenses git blame --line-porcelain output, which repeats every commit's details for each line,
+// into a line of commit, author, date, line number and content per source line
+func parseBlame(output string) []string {
+	var lines []string
+	var commit, author, date, number string
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			lines = append(lines, fmt.Sprintf("%s %s %s %s: %s", commit, author, date, number, line[1:]))
+		case key == "author":
+			author = value
+		case key == "author-time":
+			if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+				date = time.Unix(seconds, 0).UTC().Format(time.DateOnly)
+			}
+		case len(key) == 40:
+			// A header line: the commit hash, its original line, then the line in the file as it is now
+			fields := strings.Fields(value)
+			commit = key[:8]
+			if len(fields) >= 2 {
+				number = fields[1]
+			}
+		}
+	}
+
+	return lines
+}
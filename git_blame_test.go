@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGitBlame(t *testing.T) {
+	setupGitRepo(t, map[string]string{"main.go": "package main\n\nfunc main() {\n}\n"})
+	config.AllowCommands = true
+	writeFile(t, "main.go", "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	commit := exec.Command("git", "commit", "-q", "-am", "say hi")
+	commit.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Other Author", "GIT_AUTHOR_EMAIL=other@example.com", "GIT_AUTHOR_DATE=2024-02-03T10:00:00Z")
+	if output, err := commit.CombinedOutput(); err != nil {
+		t.Fatalf("git commit: %v\n%s", err, output)
+	}
+
+	got, err := callTool(t, GitBlame, map[string]any{"path": "main.go", "start_line": 3, "end_line": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), got)
+	}
+	for i, want := range []string{
+		`^[0-9a-f]{8} Test \d{4}-\d{2}-\d{2} 3: func main\(\) \{$`,
+		`^[0-9a-f]{8} Other Author 2024-02-03 4: \tprintln\("hi"\)$`,
+		`^[0-9a-f]{8} Test \d{4}-\d{2}-\d{2} 5: \}$`,
+	} {
+		if !regexp.MustCompile(want).MatchString(lines[i]) {
+			t.Errorf("line %d is %q, want it to match %s", i+1, lines[i], want)
+		}
+	}
+	if lines[0][:8] == lines[1][:8] || lines[0][:8] != lines[2][:8] {
+		t.Errorf("got commits %q, want the changed line blamed on the later commit", got)
+	}
+}
+
+func TestGitBlameErrors(t *testing.T) {
+	setupGitRepo(t, map[string]string{"main.go": "package main\n"})
+	config.AllowCommands = true
+
+	for _, lines := range [][2]int{{0, 1}, {3, 2}} {
+		if _, err := callTool(t, GitBlame, map[string]any{"path": "main.go", "start_line": lines[0], "end_line": lines[1]}); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("lines %v: got error %v, want ErrInvalidInput", lines, err)
+		}
+	}
+	if _, err := callTool(t, GitBlame, map[string]any{"path": "main.go", "start_line": 5, "end_line": 6}); err == nil || !strings.Contains(err.Error(), "git blame failed") {
+		t.Errorf("lines past the end: got error %v, want git's error", err)
+	}
+
+	setupWorkspace(t)
+	config.AllowCommands = true
+	writeFile(t, "main.go", "package main\n")
+	if _, err := callTool(t, GitBlame, map[string]any{"path": "main.go", "start_line": 1, "end_line": 1}); !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "not in a git repository") {
+		t.Errorf("outside a repository: got error %v, want ErrNotFound", err)
+	}
+}
+
+func TestGitBlameFailureNotRetried(t *testing.T) {
+	setupGitRepo(t, map[string]string{"main.go": "package main\n"})
+	config.AllowCommands = true
+	writeFile(t, "untracked.go", "package main\n")
+
+	// Count the runs of git by putting a wrapper in front of it
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	runs := filepath.Join(bin, "runs")
+	wrapper := fmt.Sprintf("#!/bin/sh\necho run >> %q\nexec %q \"$@\"\n", runs, git)
+	if err := os.WriteFile(filepath.Join(bin, "git"), []byte(wrapper), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	agent := newTestAgent(nil, []ToolDefinition{GitBlameDefinition})
+	var result ToolResult
+	captureStdout(t, func() {
+		result = agent.runTool("tool_1", "git_blame", json.RawMessage(`{"path":"untracked.go","start_line":1,"end_line":1}`))
+	})
+
+	if result.Status != ToolError || !strings.Contains(result.Message, "git blame failed") {
+		t.Errorf("got %s %q, want git's error", result.Status, result.Message)
+	}
+	if got := readFile(t, runs); got != "run\n" {
+		t.Errorf("git ran %d times, want once", strings.Count(got, "run"))
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Git states of a file that --git-check distinguishes
+const (
+	gitClean     = "clean"
+	gitModified  = "modified"
+	gitUntracked = "untracked"
+	gitUnknown   = ""
+)
+
+// gitFileState reports whether an existing file is committed, has uncommitted changes or is untracked.
+// Files outside a repository, ignored or missing files, and a missing git all report gitUnknown.
+func gitFileState(filePath string) string {
+	if _, err := os.Stat(filePath); err != nil {
+		return gitUnknown
+	}
+
+	cmd := exec.Command("git", "status", "--porcelain", "--", filepath.Base(filePath))
+	cmd.Dir = filepath.Dir(filePath)
+	output, err := cmd.Output()
+	if err != nil {
+		return gitUnknown
+	}
+
+	status := string(output)
+	switch {
+	case status == "":
+		// Ignored files are also silent, only files git knows about are clean
+		cmd = exec.Command("git", "ls-files", "--error-unmatch", "--", filepath.Base(filePath))
+		cmd.Dir = filepath.Dir(filePath)
+		if cmd.Run() != nil {
+			return gitUnknown
+		}
+		return gitClean
+	case strings.HasPrefix(status, "??"):
+		return gitUntracked
+	default:
+		return gitModified
+	}
+}
+
+// uncommittedWarning describes why changing the file at a tool supplied path can't be undone from git, or returns
+// an empty string when it can
+func uncommittedWarning(p string) string {
+	resolved, err := resolvePath(p)
+	if err != nil {
+		return ""
+	}
+
+	switch gitFileState(resolved) {
+	case gitModified:
+		return fmt.Sprintf("%s has uncommitted changes", p)
+	case gitUntracked:
+		return fmt.Sprintf("%s is not tracked by git", p)
+	}
+
+	return ""
+}
+
+// confirmEdit warns the user that a change can't be recovered from git and asks whether to make it anyway
+func (a *Agent) confirmEdit(warning string) bool {
+	a.questionPrompt("", warning+", change it anyway? [y/N]")
+	answer, ok := a.readAnswer()
+
+	return ok && isYes(answer)
+}
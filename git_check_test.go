@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// setupGitRepo runs the test in a workspace that is a git repository with files committed
+func setupGitRepo(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	setupWorkspace(t)
+	for name, content := range files {
+		writeFile(t, name, content)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "Test"},
+		{"add", "-A"},
+		{"commit", "-q", "-m", "initial"},
+	} {
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+}
+
+func TestUncommittedWarning(t *testing.T) {
+	setupGitRepo(t, map[string]string{"clean.txt": "clean\n", "dirty.txt": "dirty\n"})
+	writeFile(t, "dirty.txt", "edited\n")
+	writeFile(t, "new.txt", "new\n")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"clean.txt", ""},
+		{"dirty.txt", "dirty.txt has uncommitted changes"},
+		{"new.txt", "new.txt is not tracked by git"},
+		{"missing.txt", ""},
+	}
+
+	for _, test := range tests {
+		if got := uncommittedWarning(test.path); got != test.want {
+			t.Errorf("uncommittedWarning(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestGitCheckReplaceInFilesChecksEachFile(t *testing.T) {
+	files := map[string]string{"clean.go": "foo\n", "dirty.go": "foo\n", "other.go": "bar\n"}
+
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"n", "foo\n"},
+		{"y", "baz\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.answer, func(t *testing.T) {
+			setupGitRepo(t, files)
+			config.GitCheck = true
+			writeFile(t, "dirty.go", "foo\nfoo\n")
+			writeFile(t, "new.go", "foo\n")
+			agent := newTestAgent(nil, []ToolDefinition{ReplaceInFilesDefinition}, test.answer)
+
+			input := json.RawMessage(`{"pattern":"foo","replacement":"baz","glob":"*.go"}`)
+			var result ToolResult
+			output := captureStdout(t, func() {
+				result = agent.runTool("tool_1", "replace_in_files", input)
+			})
+
+			for _, warning := range []string{"dirty.go has uncommitted changes", "new.go is not tracked by git"} {
+				if !strings.Contains(output, warning) {
+					t.Errorf("prompt %q is missing %q", output, warning)
+				}
+			}
+			if strings.Contains(output, "clean.go") || strings.Contains(output, "other.go") {
+				t.Errorf("prompt %q warns about a committed or unchanged file", output)
+			}
+			if (result.Status == ToolError) != (test.answer == "n") {
+				t.Errorf("got status %s for answer %q", result.Status, test.answer)
+			}
+			if got := readFile(t, "new.go"); got != test.want {
+				t.Errorf("new.go has %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGitCheckSkipsCommittedFiles(t *testing.T) {
+	setupGitRepo(t, map[string]string{"clean.go": "foo\n"})
+	config.GitCheck = true
+	agent := newTestAgent(nil, []ToolDefinition{ReplaceInFilesDefinition})
+
+	input := json.RawMessage(`{"pattern":"foo","replacement":"baz"}`)
+	var result ToolResult
+	output := captureStdout(t, func() {
+		result = agent.runTool("tool_1", "replace_in_files", input)
+	})
+
+	if result.Status != ToolSuccess || strings.Contains(output, "change it anyway") {
+		t.Errorf("got status %s and prompt %q, want the edit without asking", result.Status, output)
+	}
+	if got := readFile(t, "clean.go"); got != "baz\n" {
+		t.Errorf("clean.go has %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var GlobDefinition = ToolDefinition{
+	Name:        "glob",
+	Description: "Find files whose paths match a glob pattern, such as '*.go' or 'internal/**/*_test.go'. '*' matches within a single directory and '**' matches across any number of directories. Files ignored by .gitignore are skipped. Returns the matching relative paths as a JSON array.",
+	InputSchema: GlobInputSchema,
+	Function:    Glob,
+}
+
+type GlobInput struct {
+	Pattern string `json:"pattern" jsonschema_description:"The glob pattern to match, relative to the working directory."`
+}
+
+var GlobInputSchema = GenerateSchema[GlobInput]()
+
+func Glob(input json.RawMessage) (string, error) {
+	globInput := GlobInput{}
+	err := json.Unmarshal(input, &globInput)
+	if err != nil {
+		return "", err
+	}
+
+	pattern := strings.TrimPrefix(globInput.Pattern, "./")
+	if pattern == "" {
+		return "", fmt.Errorf("pattern must not be empty: %w", ErrInvalidInput)
+	}
+	if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %w", globInput.Pattern, ErrInvalidInput)
+	}
+
+	matches, err := globFiles(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(matches)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// globFiles walks the workspace returning the slash separated relative paths of files matching pattern,
+// skipping anything ignored by .gitignore along with the .git and soft delete trash directories
+func globFiles(pattern string) ([]string, error) {
+	root, err := resolvePath(".")
+	if err != nil {
+		return nil, err
+	}
+
+	ignore, err := loadGitignore()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []string{}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if d.IsDir() && (d.Name() == ".git" || d.Name() == trashDir) || ignore.Match(p, d.IsDir()) || agentIgnored(p, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() && matchGlob(pattern, relPath) {
+			matches = append(matches, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// matchGlob matches a slash separated path against a pattern where '**' stands for any number of path segments
+// and every other segment follows path.Match
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try letting '**' swallow each possible number of leading segments
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{pattern: "*.go", want: []string{"main.go"}},
+		{pattern: "**/*.go", want: []string{"internal/deep/util.go", "internal/tool.go", "main.go"}},
+		{pattern: "internal/**/*.go", want: []string{"internal/deep/util.go", "internal/tool.go"}},
+		{pattern: "*.rs", want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "main.go", "package main\n")
+			writeFile(t, "README.md", "# readme\n")
+			writeFile(t, "internal/tool.go", "package internal\n")
+			writeFile(t, "internal/deep/util.go", "package deep\n")
+			writeFile(t, "build/generated.go", "package build\n")
+			writeFile(t, ".gitignore", "build/\n")
+
+			got, err := callTool(t, Glob, map[string]any{"pattern": test.pattern})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var matches []string
+			if err := json.Unmarshal([]byte(got), &matches); err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(matches)
+			if !slices.Equal(matches, test.want) {
+				t.Errorf("got %v, want %v", matches, test.want)
+			}
+		})
+	}
+}
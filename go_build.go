@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var GoBuildDefinition = ToolDefinition{
+	Name:           "go_build",
+	Description:    "Compile all Go packages in the working directory with 'go build ./...'. Use this after making edits to check the code still compiles. Returns either success or the list of compiler errors.",
+	InputSchema:    GoBuildInputSchema,
+	Function:       GoBuild,
+	StreamFunction: GoBuildStream,
+	Timeout:        commandToolTimeout,
+}
+
+type GoBuildInput struct{}
+
+var GoBuildInputSchema = GenerateSchema[GoBuildInput]()
+
+// compilerErrorPattern matches diagnostics of the form file.go:line[:column]: message
+var compilerErrorPattern = regexp.MustCompile(`^(\S.*?\.go):(\d+)(?::\d+)?: (.*)$`)
+
+func GoBuild(input json.RawMessage) (string, error) {
+	return GoBuildStream(input, nil)
+}
+
+func GoBuildStream(input json.RawMessage, stream io.Writer) (string, error) {
+	goBuildInput := GoBuildInput{}
+	err := json.Unmarshal(input, &goBuildInput)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runCommandStream(stream, "go", "build", "./...")
+	if err == nil {
+		return "Build succeeded", nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return "", err
+	}
+
+	diagnostics := parseDiagnostics(output)
+	if len(diagnostics) == 0 {
+		return "", fmt.Errorf("build failed: %s", strings.TrimSpace(output))
+	}
+
+	return fmt.Sprintf("Build failed with %d errors:\n%s", len(diagnostics), strings.Join(diagnostics, "\n")), nil
+}
+
+// parseDiagnostics extracts file:line: message entries from go tool output, ignoring package headers
+func parseDiagnostics(output string) []string {
+	var diagnostics []string
+	for _, line := range strings.Split(output, "\n") {
+		match := compilerErrorPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		diagnostics = append(diagnostics, fmt.Sprintf("%s:%s: %s", match[1], match[2], match[3]))
+	}
+
+	return diagnostics
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// setupGoModule creates a Go module in the workspace from a map of file names to contents, allowing commands
+func setupGoModule(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	setupWorkspace(t)
+	config.AllowCommands = true
+	config.CommandTimeout = time.Minute
+	writeFile(t, "go.mod", "module example.com/tmp\n\ngo 1.21\n")
+	for name, content := range files {
+		writeFile(t, name, content)
+	}
+}
+
+func TestGoBuildSucceeds(t *testing.T) {
+	setupGoModule(t, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	got, err := callTool(t, GoBuild, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Build succeeded" {
+		t.Errorf("got %q, want success", got)
+	}
+}
+
+func TestGoBuildReportsErrors(t *testing.T) {
+	setupGoModule(t, map[string]string{"main.go": "package main\n\nfunc main() {\n\tx :=\n}\n"})
+
+	got, err := callTool(t, GoBuild, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "Build failed with 1 errors:") || !strings.Contains(got, "main.go:5:") {
+		t.Errorf("got %q, want the syntax error on main.go line 5", got)
+	}
+}
+
+func TestParseDiagnostics(t *testing.T) {
+	output := "# example.com/tmp\n./main.go:4:2: undefined: x\n"
+
+	got := parseDiagnostics(output)
+	want := []string{"./main.go:4: undefined: x"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var GoDocDefinition = ToolDefinition{
+	Name:        "go_doc",
+	Description: "Look up Go documentation with 'go doc'. Use this to check the API of an unfamiliar package, type, function or method, e.g. 'fmt.Println', 'net/http.Client' or 'strings'.",
+	InputSchema: GoDocInputSchema,
+	Function:    GoDoc,
+	Timeout:     commandToolTimeout,
+}
+
+type GoDocInput struct {
+	Symbol string `json:"symbol" jsonschema_description:"The package path or symbol to document, e.g. 'fmt.Println' or 'encoding/json'."`
+}
+
+var GoDocInputSchema = GenerateSchema[GoDocInput]()
+
+func GoDoc(input json.RawMessage) (string, error) {
+	goDocInput := GoDocInput{}
+	err := json.Unmarshal(input, &goDocInput)
+	if err != nil {
+		return "", err
+	}
+
+	symbol := strings.TrimSpace(goDocInput.Symbol)
+	if symbol == "" || strings.HasPrefix(symbol, "-") {
+		return "", fmt.Errorf("symbol must be a package path or symbol name: %w", ErrInvalidInput)
+	}
+
+	output, err := runCommand("go", "doc", symbol)
+	if err != nil {
+		if output != "" {
+			return "", fmt.Errorf("go doc %s failed: %s", symbol, strings.TrimSpace(output))
+		}
+		return "", err
+	}
+
+	return output, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGoDocStdlibSymbol(t *testing.T) {
+	setupGoModule(t, nil)
+
+	got, err := callTool(t, GoDoc, map[string]any{"symbol": "strings.TrimSpace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "func TrimSpace(s string) string") {
+		t.Errorf("got %q, want the TrimSpace signature", got)
+	}
+}
+
+func TestGoDocInvalidSymbol(t *testing.T) {
+	setupGoModule(t, nil)
+
+	if _, err := callTool(t, GoDoc, map[string]any{"symbol": "strings.NoSuchFunction"}); err == nil {
+		t.Error("got no error for an unknown symbol")
+	}
+
+	_, err := callTool(t, GoDoc, map[string]any{"symbol": "-all"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("flag as symbol: got error %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestGoDocRequiresCommands(t *testing.T) {
+	setupWorkspace(t)
+
+	_, err := callTool(t, GoDoc, map[string]any{"symbol": "fmt"})
+	if !errors.Is(err, ErrCommandsDisabled) {
+		t.Errorf("got error %v, want ErrCommandsDisabled", err)
+	}
+}
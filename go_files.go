@@ -0,0 +1,57 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// walkGoFiles parses every Go file in the workspace and calls fn with its slash separated relative path.
+// Hidden, vendor, testdata and .gitignore'd directories are skipped, as are files that fail to parse.
+func walkGoFiles(fn func(relPath string, fset *token.FileSet, file *ast.File)) error {
+	root, err := resolvePath(".")
+	if err != nil {
+		return err
+	}
+
+	ignore, err := loadGitignore()
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if d.IsDir() {
+			name := d.Name()
+			if relPath != "." && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" || ignore.Match(p, true) || agentIgnored(p, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(p) != ".go" || ignore.Match(p, false) || agentIgnored(p, false) {
+			return nil
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, p, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil
+		}
+		fn(relPath, fset, file)
+
+		return nil
+	})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var HeadFileDefinition = ToolDefinition{
+	Name:        "head_file",
+	Description: "Read the first lines of a file, stopping early rather than reading the whole file. Use this to check imports, headers or the start of a large file.",
+	InputSchema: HeadFileInputSchema,
+	Function:    HeadFile,
+	Retries:     readToolRetries,
+}
+
+type HeadFileInput struct {
+	Path  string `json:"path" jsonschema_description:"The relative path of the file to read."`
+	Lines int    `json:"lines,omitempty" jsonschema_description:"The number of leading lines to return. Defaults to 50."`
+}
+
+var HeadFileInputSchema = GenerateSchema[HeadFileInput]()
+
+func HeadFile(input json.RawMessage) (string, error) {
+	headFileInput := HeadFileInput{}
+	err := json.Unmarshal(input, &headFileInput)
+	if err != nil {
+		return "", err
+	}
+
+	lines := headFileInput.Lines
+	if lines == 0 {
+		lines = defaultLineCount
+	}
+	if lines < 0 {
+		return "", fmt.Errorf("lines must be positive: %w", ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(headFileInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkRegularFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(resolved)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var head strings.Builder
+	reader := bufio.NewReader(file)
+	for i := 0; i < lines; i++ {
+		line, err := reader.ReadString('\n')
+		head.WriteString(line)
+
+		// Very long lines could still add up to a huge result, so apply the file size limit to what is returned
+		if config.MaxFileSize > 0 && int64(head.Len()) > config.MaxFileSize {
+			return head.String()[:config.MaxFileSize] + fmt.Sprintf("\n[truncated at %d bytes]", config.MaxFileSize), nil
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return strings.TrimSuffix(head.String(), "\n"), nil
+}
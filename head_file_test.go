@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHeadFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int
+		lines int
+		want  string
+	}{
+		{name: "more lines than requested", total: 100, lines: 2, want: "line 1\nline 2"},
+		{name: "fewer lines than requested", total: 3, lines: 10, want: "line 1\nline 2\nline 3"},
+		{name: "default count", total: 60, want: strings.TrimSuffix(numberedLines(50), "\n")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "file.txt", numberedLines(test.total))
+
+			input := map[string]any{"path": "file.txt"}
+			if test.lines != 0 {
+				input["lines"] = test.lines
+			}
+			got, err := callTool(t, HeadFile, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHeadFileTruncatesLongLines(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxFileSize = 10
+	writeFile(t, "file.txt", strings.Repeat("x", 100)+"\n")
+
+	got, err := callTool(t, HeadFile, map[string]any{"path": "file.txt", "lines": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Repeat("x", 10) + "\n[truncated at 10 bytes]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHeadFileOutsideWorkspace(t *testing.T) {
+	setupWorkspace(t)
+
+	_, err := callTool(t, HeadFile, map[string]any{"path": "../secret.txt"})
+	if !errors.Is(err, ErrOutsideWorkspace) {
+		t.Errorf("got error %v, want ErrOutsideWorkspace", err)
+	}
+}
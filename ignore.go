@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a single pattern line from a .gitignore style file
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreMatcher decides whether workspace paths are excluded by a set of .gitignore style rules
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadIgnoreFile parses a .gitignore style file, returning an empty matcher when it doesn't exist
+func loadIgnoreFile(filePath string) (*ignoreMatcher, error) {
+	matcher := &ignoreMatcher{}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return matcher, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		// A slash anywhere but the end ties the pattern to the root rather than any directory level
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		rule.pattern = line
+		matcher.rules = append(matcher.rules, rule)
+	}
+
+	return matcher, scanner.Err()
+}
+
+// Match reports whether the slash separated path, relative to the workspace root, is ignored.
+// Later rules override earlier ones, so a negated pattern can re-include a path.
+func (m *ignoreMatcher) Match(relPath string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		var matched bool
+		if rule.anchored {
+			matched = matchGlob(rule.pattern, relPath)
+		} else {
+			matched = matchGlob(rule.pattern, path.Base(relPath))
+		}
+
+		if matched {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+// matchWithin reports whether an absolute path beneath root, or any directory between them, is ignored
+func (m *ignoreMatcher) matchWithin(root, absPath string, isDir bool) bool {
+	relPath, err := filepath.Rel(root, absPath)
+	if err != nil || relPath == "." {
+		return false
+	}
+
+	segments := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := range segments {
+		last := i == len(segments)-1
+		if m.Match(strings.Join(segments[:i+1], "/"), !last || isDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gitignore holds the rules of the .gitignore at the workspace root, which apply relative to the root even when
+// a walk starts in the working directory beneath it
+type gitignore struct {
+	root  string
+	rules *ignoreMatcher
+}
+
+// loadGitignore reads the .gitignore at the workspace root
+func loadGitignore() (*gitignore, error) {
+	root, err := workspaceRoot()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := loadIgnoreFile(filepath.Join(root, ".gitignore"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitignore{root: root, rules: rules}, nil
+}
+
+// Match reports whether an absolute path, or any directory above it, is ignored
+func (g *gitignore) Match(absPath string, isDir bool) bool {
+	return g.rules.matchWithin(g.root, absPath, isDir)
+}
+
+// agentIgnoreFile lists paths, in .gitignore syntax, that tools must treat as if they don't exist
+const agentIgnoreFile = ".agentignore"
+
+// agentIgnore holds the rules loaded from .agentignore at startup, nil when there are none
+var agentIgnore *ignoreMatcher
+
+// agentIgnored reports whether an absolute path, or any directory above it, is hidden by .agentignore
+func agentIgnored(absPath string, isDir bool) bool {
+	if agentIgnore == nil {
+		return false
+	}
+
+	root, err := workspaceRoot()
+	if err != nil {
+		return false
+	}
+
+	return agentIgnore.matchWithin(root, absPath, isDir)
+}
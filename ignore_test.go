@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setupAgentIgnore writes a workspace with a .agentignore hiding secrets and loads it as startup does
+func setupAgentIgnore(t *testing.T) {
+	t.Helper()
+
+	setupWorkspace(t)
+	writeFile(t, agentIgnoreFile, "secret.env\ndata/\n")
+	writeFile(t, "main.go", "package main // token\n")
+	writeFile(t, "secret.env", "API_KEY=token\n")
+	writeFile(t, "data/dump.csv", "token\n")
+
+	var err error
+	agentIgnore, err = loadIgnoreFile(agentIgnoreFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAgentIgnoreHidesFromReading(t *testing.T) {
+	setupAgentIgnore(t)
+
+	for _, p := range []string{"secret.env", "data/dump.csv", "./data"} {
+		if _, err := callTool(t, ReadFile, map[string]any{"path": p}); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("read_file %s: got %v, want ErrNotExist", p, err)
+		}
+	}
+	if _, err := callTool(t, NewCountTokensDefinition(nil).Function, map[string]any{"path": "secret.env"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("count_tokens: got %v, want ErrNotExist", err)
+	}
+	if got, err := callTool(t, ReadFile, map[string]any{"path": "main.go"}); err != nil || !strings.Contains(got, "token") {
+		t.Errorf("read_file main.go: got %q, %v", got, err)
+	}
+}
+
+func TestAgentIgnoreHidesFromListing(t *testing.T) {
+	setupAgentIgnore(t)
+
+	listed, err := callTool(t, ListFiles, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	globbed, err := callTool(t, Glob, map[string]any{"pattern": "**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, got := range map[string]string{"list_files": listed, "glob": globbed} {
+		if !strings.Contains(got, "main.go") {
+			t.Errorf("%s = %s, want main.go", name, got)
+		}
+		if strings.Contains(got, "secret.env") || strings.Contains(got, "data") {
+			t.Errorf("%s = %s, reveals an ignored path", name, got)
+		}
+	}
+}
+
+func TestAgentIgnoreHidesFromSearching(t *testing.T) {
+	setupAgentIgnore(t)
+
+	got, err := callTool(t, ReplaceInFiles, map[string]any{"pattern": "token", "replacement": "TOKEN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `[{"file":"main.go","replacements":1}]`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := readFile(t, "secret.env"); got != "API_KEY=token\n" {
+		t.Errorf("secret.env was changed to %q", got)
+	}
+}
+
+func TestGitignoreAppliesAfterChangeDirectory(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, ".gitignore", "build/\n*.log\n/generated.go\nsub/secret/\n")
+	for _, name := range []string{"generated.go", "sub/app.go", "sub/debug.log", "sub/build/out.go", "sub/generated.go", "sub/secret/key.go"} {
+		writeFile(t, name, "package sub\n")
+	}
+
+	tests := []struct {
+		dir  string
+		want []string
+	}{
+		{"sub", []string{"app.go", "generated.go"}},
+		{"sub/secret", []string{}},
+	}
+
+	for _, test := range tests {
+		setWorkDir(".")
+		if _, err := callTool(t, ChangeDirectory, map[string]any{"path": test.dir}); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := globFiles("**")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("in %s: got %v, want %v", test.dir, got, test.want)
+		}
+
+		var goFiles []string
+		if err := walkGoFiles(func(relPath string, _ *token.FileSet, _ *ast.File) { goFiles = append(goFiles, relPath) }); err != nil {
+			t.Fatal(err)
+		}
+		if want := slices.DeleteFunc(slices.Clone(test.want), func(name string) bool { return !strings.HasSuffix(name, ".go") }); !slices.Equal(goFiles, want) {
+			t.Errorf("in %s: walked Go files %v, want %v", test.dir, goFiles, want)
+		}
+	}
+}
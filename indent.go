@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+)
+
+// defaultIndentWidth is assumed for space indented code when no nesting shows how wide a level is
+const defaultIndentWidth = 4
+
+// indentStyle is the unit of indentation in a file: a tab, or a number of spaces
+type indentStyle struct {
+	tabs  bool
+	width int
+}
+
+func (s indentStyle) unit() string {
+	if s.tabs {
+		return "\t"
+	}
+	return strings.Repeat(" ", s.width)
+}
+
+// detectIndent returns the indentation most indented lines of content use, reporting false when no line is indented.
+// The width of space indentation is taken from the most common step between the indents of consecutive lines.
+func detectIndent(content string) (indentStyle, bool) {
+	tabLines, spaceLines := 0, 0
+	steps := map[int]int{}
+	previous := 0
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		switch line[0] {
+		case '\t':
+			tabLines++
+		case ' ':
+			spaceLines++
+		}
+
+		spaces := len(line) - len(strings.TrimLeft(line, " "))
+		if step := spaces - previous; step > 0 {
+			steps[step]++
+		}
+		previous = spaces
+	}
+
+	if tabLines == 0 && spaceLines == 0 {
+		return indentStyle{}, false
+	}
+	if tabLines >= spaceLines {
+		return indentStyle{tabs: true}, true
+	}
+
+	width, count := defaultIndentWidth, 0
+	for step, n := range steps {
+		if n > count || n == count && step < width {
+			width, count = step, n
+		}
+	}
+
+	return indentStyle{width: width}, true
+}
+
+// reindent rewrites the leading indentation of content from its own style to style, keeping any partial level of
+// spaces, such as alignment, as it is
+func reindent(content string, style indentStyle) string {
+	from, ok := detectIndent(content)
+	if !ok || from == style {
+		return content
+	}
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		body := strings.TrimLeft(line, " \t")
+		leading := line[:len(line)-len(body)]
+
+		levels, spaces := 0, 0
+		for _, r := range leading {
+			if r == '\t' {
+				levels, spaces = levels+1, 0
+				continue
+			}
+			spaces++
+			if !from.tabs && spaces == from.width {
+				levels, spaces = levels+1, 0
+			}
+		}
+
+		lines[i] = strings.Repeat(style.unit(), levels) + strings.Repeat(" ", spaces) + body
+	}
+
+	return strings.Join(lines, "\n")
+}
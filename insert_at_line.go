@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var InsertAtLineDefinition = ToolDefinition{
+	Name:        "insert_at_line",
+	Description: "Insert content into a file before the given 1-based line, or at the end with the line after the last. The content is re-indented to match the file's tabs or spaces unless keep_indentation is set.",
+	InputSchema: InsertAtLineInputSchema,
+	Function:    InsertAtLine,
+	Mutates:     true,
+}
+
+type InsertAtLineInput struct {
+	Path            string `json:"path" jsonschema_description:"The relative path of the file to insert into."`
+	Line            int    `json:"line" jsonschema_description:"The line the content is inserted before (1-based). Use one more than the number of lines to append."`
+	Content         string `json:"content" jsonschema_description:"The lines to insert."`
+	KeepIndentation bool   `json:"keep_indentation,omitempty" jsonschema_description:"Insert the content exactly as given rather than converting its indentation to the file's style."`
+}
+
+var InsertAtLineInputSchema = GenerateSchema[InsertAtLineInput]()
+
+func InsertAtLine(input json.RawMessage) (string, error) {
+	insertAtLineInput := InsertAtLineInput{}
+	err := json.Unmarshal(input, &insertAtLineInput)
+	if err != nil {
+		return "", err
+	}
+
+	if insertAtLineInput.Path == "" || insertAtLineInput.Content == "" {
+		return "", fmt.Errorf("invalid input parameters: %w", ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(insertAtLineInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := readTextFile(resolved, false)
+	if err != nil {
+		return "", err
+	}
+
+	// Split keeping the line terminators so the rest of the file is written back byte for byte
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	line := insertAtLineInput.Line
+	if line < 1 || line > len(lines)+1 {
+		return "", fmt.Errorf("invalid line %d, file has %d lines: %w", line, len(lines), ErrInvalidInput)
+	}
+
+	inserted := insertAtLineInput.Content
+	if style, ok := detectIndent(content); ok && !insertAtLineInput.KeepIndentation {
+		inserted = reindent(inserted, style)
+	}
+	if !strings.HasSuffix(inserted, "\n") {
+		inserted += "\n"
+	}
+	count := strings.Count(inserted, "\n")
+	// Appending to a file without a final newline would otherwise join its last line to the content
+	if line == len(lines)+1 && len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		inserted = "\n" + inserted
+	}
+
+	newContent := strings.Join(lines[:line-1], "") + inserted + strings.Join(lines[line-1:], "")
+
+	err = backupFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	formatted, note := autoFormat(resolved, []byte(newContent))
+	err = writeFileAtomic(resolved, formatted, info.Mode().Perm())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Inserted %d lines at line %d of %s%s", count, line, insertAtLineInput.Path, note), nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    indentStyle
+		ok      bool
+	}{
+		{"tabs", "func f() {\n\tif x {\n\t\treturn\n\t}\n}\n", indentStyle{tabs: true}, true},
+		{"two spaces", "a:\n  b:\n    c: 1\n  d: 2\n", indentStyle{width: 2}, true},
+		{"four spaces", "def f():\n    if x:\n        return\n", indentStyle{width: 4}, true},
+		{"flat", "one\ntwo\n", indentStyle{}, false},
+	}
+
+	for _, test := range tests {
+		got, ok := detectIndent(test.content)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s: got %+v, %t, want %+v, %t", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestReindent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		style   indentStyle
+		want    string
+	}{
+		{"spaces to tabs", "if x {\n    y()\n        z()\n}", indentStyle{tabs: true}, "if x {\n\ty()\n\t\tz()\n}"},
+		{"tabs to spaces", "if x:\n\ty()\n\t\tz()", indentStyle{width: 2}, "if x:\n  y()\n    z()"},
+		{
+			"keeps alignment",
+			"if x {\n    f(a,\n      b)\n    if y {\n        g()\n    }\n}",
+			indentStyle{tabs: true},
+			"if x {\n\tf(a,\n\t  b)\n\tif y {\n\t\tg()\n\t}\n}",
+		},
+		{"same style", "a\n\tb", indentStyle{tabs: true}, "a\n\tb"},
+	}
+
+	for _, test := range tests {
+		if got := reindent(test.content, test.style); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestInsertAtLineMatchesIndentation(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "tabs.txt", "start {\n\tfirst\n}\n")
+	writeFile(t, "spaces.py", "def f():\n  return 1\n")
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		path  string
+		want  string
+	}{
+		{
+			"tab indented file",
+			map[string]any{"path": "tabs.txt", "line": 3, "content": "    second\n    third"},
+			"tabs.txt",
+			"start {\n\tfirst\n\tsecond\n\tthird\n}\n",
+		},
+		{
+			"space indented file",
+			map[string]any{"path": "spaces.py", "line": 3, "content": "def g():\n\tif x:\n\t\treturn 2\n"},
+			"spaces.py",
+			"def f():\n  return 1\ndef g():\n  if x:\n    return 2\n",
+		},
+		{
+			"opted out",
+			map[string]any{"path": "tabs.txt", "line": 2, "content": "    kept\n", "keep_indentation": true},
+			"tabs.txt",
+			"start {\n    kept\n\tfirst\n\tsecond\n\tthird\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		if _, err := callTool(t, InsertAtLine, test.input); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := readFile(t, test.path); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestInsertAtLineAppends(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "notes.txt", "one\ntwo")
+
+	got, err := callTool(t, InsertAtLine, map[string]any{"path": "notes.txt", "line": 3, "content": "three\nfour"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Inserted 2 lines at line 3 of notes.txt"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := readFile(t, "notes.txt"); got != "one\ntwo\nthree\nfour\n" {
+		t.Errorf("file has %q", got)
+	}
+
+	for _, line := range []int{0, 6} {
+		if _, err := callTool(t, InsertAtLine, map[string]any{"path": "notes.txt", "line": line, "content": "x"}); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("line %d: got %v, want ErrInvalidInput", line, err)
+		}
+	}
+}
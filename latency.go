@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// latencyTotals accumulates where the time of a session went: waiting on Claude, running tools or waiting on the user
+type latencyTotals struct {
+	inference time.Duration
+	tools     time.Duration
+	idle      time.Duration
+
+	// now is the clock, swappable so the totals can be checked without waiting
+	now func() time.Time
+}
+
+// measure starts timing something, the returned stop adds the time since to total and returns it
+func (l *latencyTotals) measure(total *time.Duration) (stop func() time.Duration) {
+	now := l.now
+	if now == nil {
+		now = time.Now
+	}
+
+	start := now()
+	return func() time.Duration {
+		elapsed := now().Sub(start)
+		*total += elapsed
+		return elapsed
+	}
+}
+
+func (l *latencyTotals) String() string {
+	return fmt.Sprintf("inference %s, tools %s, idle %s", formatDuration(l.inference), formatDuration(l.tools), formatDuration(l.idle))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// steppingClock returns a clock that moves on by step every time it is read
+func steppingClock(step time.Duration) func() time.Time {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestLatencyTotals(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	totals := latencyTotals{now: func() time.Time { return clock }}
+
+	steps := []struct {
+		total   *time.Duration
+		elapsed time.Duration
+	}{
+		{&totals.idle, 3 * time.Minute},
+		{&totals.inference, 30 * time.Second},
+		{&totals.tools, 12 * time.Second},
+		{&totals.inference, 10 * time.Second},
+		{&totals.idle, 250 * time.Millisecond},
+	}
+	for _, step := range steps {
+		stop := totals.measure(step.total)
+		clock = clock.Add(step.elapsed)
+		if got := stop(); got != step.elapsed {
+			t.Errorf("stop returned %s, want %s", got, step.elapsed)
+		}
+	}
+
+	if got, want := totals.String(), "inference 40s, tools 12s, idle 3m0.3s"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLatencyReportedOnExit(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "greeting.txt", "hello")
+
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "It says hello", nil))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition}, "read greeting.txt")
+	agent.latency.now = steppingClock(time.Second)
+
+	output := captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// Two requests, one tool call and two reads of input, the last finding the end of it
+	if want := "inference 2s, tools 1s, idle 2s"; !strings.Contains(output, want) {
+		t.Errorf("output %q lacks %q", output, want)
+	}
+}
+
+func TestLatencyCountsPromptsAsIdle(t *testing.T) {
+	setupWorkspace(t)
+	config.ToolPolicies = map[string]string{"read_file": PolicyAsk}
+	writeFile(t, "greeting.txt", "hello")
+
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "It says hello", nil))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition}, "read greeting.txt", "y")
+	agent.latency.now = steppingClock(time.Second)
+
+	output := captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// Answering whether the tool may run is time spent waiting on the user, not running the tool
+	if want := "inference 2s, tools 1s, idle 3s"; !strings.Contains(output, want) {
+		t.Errorf("output %q lacks %q", output, want)
+	}
+	if want := "read_file finished [1s]"; !strings.Contains(output, want) {
+		t.Errorf("output %q lacks %q", output, want)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+)
+
+var ListSymbolsDefinition = ToolDefinition{
+	Name:        "list_symbols",
+	Description: "List the top-level functions, methods and types declared in a Go file along with their line numbers. Use this to get an outline of a Go file without reading all of it.",
+	InputSchema: ListSymbolsInputSchema,
+	Function:    ListSymbols,
+}
+
+type ListSymbolsInput struct {
+	Path string `json:"path" jsonschema_description:"The relative path of a Go source file."`
+}
+
+var ListSymbolsInputSchema = GenerateSchema[ListSymbolsInput]()
+
+type goSymbol struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Receiver string `json:"receiver,omitempty"`
+	Line     int    `json:"line"`
+	EndLine  int    `json:"end_line"`
+}
+
+func ListSymbols(input json.RawMessage) (string, error) {
+	listSymbolsInput := ListSymbolsInput{}
+	err := json.Unmarshal(input, &listSymbolsInput)
+	if err != nil {
+		return "", err
+	}
+
+	fset, file, err := parseGoFile(listSymbolsInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	symbols := []goSymbol{}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			symbol := goSymbol{
+				Name:    d.Name.Name,
+				Kind:    "function",
+				Line:    fset.Position(d.Pos()).Line,
+				EndLine: fset.Position(d.End()).Line,
+			}
+			if d.Recv != nil {
+				symbol.Kind = "method"
+				symbol.Receiver = receiverTypeName(d)
+			}
+			symbols = append(symbols, symbol)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				symbols = append(symbols, goSymbol{
+					Name:    typeSpec.Name.Name,
+					Kind:    "type",
+					Line:    fset.Position(typeSpec.Pos()).Line,
+					EndLine: fset.Position(typeSpec.End()).Line,
+				})
+			}
+		}
+	}
+
+	result, err := json.Marshal(symbols)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// parseGoFile parses a Go source file within the workspace, rejecting files that aren't Go
+func parseGoFile(filePath string) (*token.FileSet, *ast.File, error) {
+	if filepath.Ext(filePath) != ".go" {
+		return nil, nil, fmt.Errorf("%s is not a Go source file: %w", filePath, ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkRegularFile(resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, resolved, nil, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+
+	return fset, file, nil
+}
+
+// receiverTypeName returns the receiver type of a method without pointer or type parameters, e.g. Agent for (a *Agent)
+func receiverTypeName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+
+	expr := decl.Recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+const listSymbolsSource = `package shapes
+
+type Square struct {
+	Side int
+}
+
+func (s *Square) Area() int {
+	return s.Side * s.Side
+}
+
+func NewSquare(side int) Square {
+	return Square{Side: side}
+}
+`
+
+func TestListSymbols(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "shapes.go", listSymbolsSource)
+
+	got, err := callTool(t, ListSymbols, map[string]any{"path": "shapes.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var symbols []goSymbol
+	if err := json.Unmarshal([]byte(got), &symbols); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []goSymbol{
+		{Name: "Square", Kind: "type", Line: 3, EndLine: 5},
+		{Name: "Area", Kind: "method", Receiver: "Square", Line: 7, EndLine: 9},
+		{Name: "NewSquare", Kind: "function", Line: 11, EndLine: 13},
+	}
+	if !slices.Equal(symbols, want) {
+		t.Errorf("got %+v, want %+v", symbols, want)
+	}
+}
+
+func TestListSymbolsRejectsBadFiles(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "notes.txt", "not go\n")
+	writeFile(t, "broken.go", "package broken\n\nfunc {\n")
+
+	_, err := callTool(t, ListSymbols, map[string]any{"path": "notes.txt"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("non-Go file: got error %v, want ErrInvalidInput", err)
+	}
+
+	if _, err := callTool(t, ListSymbols, map[string]any{"path": "broken.go"}); err == nil {
+		t.Error("unparseable file: got no error")
+	}
+}
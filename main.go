@@ -2,35 +2,261 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
 	"github.com/invopop/jsonschema"
 )
 
 const (
+	ANSI_RED    = "\u001b[91m"
 	ANSI_GREEN  = "\u001b[92m"
 	ANSI_BLUE   = "\u001b[94m"
 	ANSI_YELLOW = "\u001b[93m"
+	ANSI_DIM    = "\u001b[2m"
 	ANSI_RESET  = "\u001b[0m"
 )
 
+// reviewPreviewLines is how much of a tool result is shown when asking the user to review it
+const reviewPreviewLines = 20
+
 func main() {
-	client := anthropic.NewClient()
+	cfg, err := ParseFlags(os.Args[1:])
+	if err != nil {
+		os.Exit(2)
+	}
+	config = cfg
+
+	if err := enterWorkingDir(&config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	agentIgnore, err = loadIgnoreFile(agentIgnoreFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read %s: %v\n", agentIgnoreFile, err)
+		os.Exit(1)
+	}
+
+	// The rendered template is sent just as a --first-user-message would be
+	if config.Template != "" {
+		config.FirstUserMessage, err = renderTemplate(config.Template, config.TemplateVars)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to render template: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// A replay is answered from its recording, so it needs no API key
+	var clientOptions []option.RequestOption
+	var replayPrompts []string
+	if config.Replay != "" {
+		recording, err := loadSession(config.Replay)
+		if err == nil && recording == nil {
+			err = fmt.Errorf("%s not found", config.Replay)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to load replay: %v\n", err)
+			os.Exit(1)
+		}
+		var replies []anthropic.MessageParam
+		replayPrompts, replies = replayRecording(recording)
+		clientOptions = append(clientOptions, option.WithAPIKey("replay"), option.WithMaxRetries(0), option.WithMiddleware(replayMiddleware(replies)))
+	} else if err := checkAPIKey(os.Getenv); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := anthropic.NewClient(clientOptions...)
 	userMessageFn := UserMessage()
-	tools := []ToolDefinition{ReadFileDefinition, ListFilesDefinition, EditFileDefinition}
+	tools := []ToolDefinition{
+		ReadFileDefinition,
+		ListFilesDefinition,
+		EditFileDefinition,
+		DeleteLinesDefinition,
+		NewCountTokensDefinition(&client),
+		GoBuildDefinition,
+		VetDefinition,
+		DiffFilesDefinition,
+		ReadFilesDefinition,
+		FetchURLDefinition,
+		GlobDefinition,
+		GoDocDefinition,
+		ListSymbolsDefinition,
+		RenameSymbolDefinition,
+		FileMetricsDefinition,
+		TailFileDefinition,
+		HeadFileDefinition,
+		EnvironmentInfoDefinition,
+		ReplaceFileDefinition,
+		FindDefinitionDefinition,
+		FindReferencesDefinition,
+		DeleteFileDefinition,
+		RestoreFileDefinition,
+		ChangeDirectoryDefinition,
+		ReadFunctionDefinition,
+		CheckSyntaxDefinition,
+		ReplaceInFilesDefinition,
+		GitAddDefinition,
+		FileHashDefinition,
+		PackageAPIDefinition,
+		RunTestDefinition,
+		PeekDefinition,
+		CreateFileDefinition,
+		RecentFilesDefinition,
+		InsertAtLineDefinition,
+		GitBlameDefinition,
+		ChmodDefinition,
+	}
+
+	// Full-auto mode is easy to leave on by accident, so make it loud and confirmed
+	if config.SkipPermissions && !confirmSkipPermissions(os.Stderr, isTerminal(os.Stderr), userMessageFn) {
+		os.Exit(1)
+	}
+
+	tools, err = filterTools(tools, config.EnabledTools, config.DisabledTools)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	if config.NoTools {
+		tools = nil
+	}
+
+	contextFiles, err := loadContextFiles(config.ContextFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	system, err := systemPrompt(config.Persona, config.System, contextFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	agent := NewAgent(&client, userMessageFn, tools)
-	if err := agent.Run(context.TODO()); err != nil {
+	agent.system = system
+
+	if config.Session != "" {
+		agent.conversation, err = loadSession(config.Session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to load session: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Ctrl+C ends the session cleanly the same way reaching the end of input does, a second Ctrl+C forces it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if config.Replay != "" {
+		if err := agent.Replay(ctx, replayPrompts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// A prompt given on the command line runs a single non-interactive turn
+	if config.Prompt != "" {
+		if err := agent.RunOnce(ctx, config.Prompt); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := agent.Run(ctx); err != nil {
 		fmt.Printf("Error: %v\n", err)
 	}
 }
 
+// checkAPIKey verifies credentials for the Anthropic client are available in the environment, so a missing key is
+// reported at startup rather than as a cryptic failure on the first request
+func checkAPIKey(getenv func(string) string) error {
+	if strings.TrimSpace(getenv("ANTHROPIC_API_KEY")) != "" || strings.TrimSpace(getenv("ANTHROPIC_AUTH_TOKEN")) != "" {
+		return nil
+	}
+
+	return fmt.Errorf("ANTHROPIC_API_KEY is not set; create a key at https://console.anthropic.com/ and export it, e.g. export ANTHROPIC_API_KEY=sk-ant-...")
+}
+
+// enterWorkingDir changes into --working-dir, if given, making it the workspace root for tools and commands.
+// Files named on the command line are resolved first so they are still found, or land, relative to where the
+// agent was started. A --template given by name is left to be looked up in the new workspace.
+func enterWorkingDir(cfg *Config) error {
+	if cfg.WorkingDir == "" {
+		return nil
+	}
+
+	filePaths := []*string{&cfg.Transcript, &cfg.ExportPath, &cfg.Session, &cfg.Replay}
+	if templateFile(cfg.Template) == cfg.Template {
+		filePaths = append(filePaths, &cfg.Template)
+	}
+	for _, filePath := range filePaths {
+		if *filePath == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*filePath)
+		if err != nil {
+			return err
+		}
+		*filePath = abs
+	}
+
+	return os.Chdir(cfg.WorkingDir)
+}
+
+// filterTools narrows the registered tools to those enabled, if any are named, minus those disabled.
+// Naming a tool that doesn't exist is an error so typos don't silently leave a tool exposed.
+func filterTools(tools []ToolDefinition, enabled, disabled []string) ([]ToolDefinition, error) {
+	known := map[string]bool{}
+	for _, tool := range tools {
+		known[tool.Name] = true
+	}
+	for _, name := range append(slices.Clone(enabled), disabled...) {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown tool %q", name)
+		}
+	}
+
+	filtered := []ToolDefinition{}
+	for _, tool := range tools {
+		if len(enabled) > 0 && !slices.Contains(enabled, tool.Name) {
+			continue
+		}
+		if slices.Contains(disabled, tool.Name) {
+			continue
+		}
+		filtered = append(filtered, tool)
+	}
+
+	return filtered, nil
+}
+
 // UserMessage captures user input from the CLI and returns it via a closure
 func UserMessage() func() (string, bool) {
 	scanner := bufio.NewScanner(os.Stdin)
@@ -48,6 +274,31 @@ type Agent struct {
 	client         *anthropic.Client
 	getUserMessage func() (string, bool)
 	tools          []ToolDefinition
+	colors         bool
+	jsonOutput     bool
+	system         string
+	conversation   []anthropic.MessageParam
+	usage          usageTotals
+	latency        latencyTotals
+	contextTokens  int64
+	turns          int
+	model          anthropic.Model
+	temperature    *float64
+	limiter        *rateLimiter
+	changes        map[string]string
+	width          int
+	// approvals holds the user's batch decisions, by tool use ID, for the calls of the message being run
+	approvals map[string]bool
+	// progress is where a spinner is shown while waiting on Claude, nil when there's no terminal to show it on
+	progress io.Writer
+}
+
+// usageTotals accumulates token usage across every request of a session
+type usageTotals struct {
+	InputTokens  int64
+	OutputTokens int64
+	// ThinkingTokens is the estimated part of OutputTokens spent on extended thinking
+	ThinkingTokens int64
 }
 
 // NewAgent creates a new instance of an Agent
@@ -56,43 +307,293 @@ func NewAgent(
 	getUserMessage func() (string, bool),
 	tools []ToolDefinition,
 ) *Agent {
+	width := config.Width
+	if width == 0 {
+		width = terminalWidth()
+	}
+
+	var limiter *rateLimiter
+	if config.RPM > 0 {
+		limiter = newRateLimiter(config.RPM)
+	}
+
+	// A spinner would only garble output that is piped or parsed
+	var progress io.Writer
+	if isTerminal(os.Stdout) && config.Output != OutputJSON && !config.Quiet {
+		progress = os.Stdout
+	}
+
 	return &Agent{
 		client:         client,
 		getUserMessage: getUserMessage,
 		tools:          tools,
+		colors:         isTerminal(os.Stdout),
+		jsonOutput:     config.Output == OutputJSON,
+		model:          anthropic.Model(config.Model),
+		temperature:    config.Temperature,
+		limiter:        limiter,
+		width:          width,
+		progress:       progress,
 	}
 }
 
 // Run starts a conversation with Claude
 func (a *Agent) Run(ctx context.Context) error {
-	conversation := []anthropic.MessageParam{}
+	if !a.jsonOutput && !config.Quiet {
+		fmt.Println("Chat with Claude (use 'ctrl+C' to exit)")
+	}
 
-	fmt.Println("Chat with Claude (use 'ctrl+C' to exit)")
+	// The session deadline only stops waiting for input, a turn in progress is left to finish
+	sessionCtx := ctx
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		sessionCtx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	if config.Watch != "" {
+		go a.watch(sessionCtx, config.Watch)
+	}
+
+	// A seeded first message is answered before any input is read, as if the user had typed it
+	var err error
+	if config.FirstUserMessage != "" {
+		a.requestPrompt()
+		if !a.jsonOutput {
+			fmt.Println(config.FirstUserMessage)
+		}
+		err = a.userTurn(ctx, config.FirstUserMessage)
+	}
 
 	// Run a continuous capture sesssion for chatting with Claude
-	readUserInput := true
+	for err == nil && sessionCtx.Err() == nil {
+		// Capture user input from the CLI, the end of input or an interrupt ends the session
+		a.requestPrompt()
+		userInput, ok := a.readUserMessage(sessionCtx)
+		if !ok {
+			// Finish the unanswered prompt line before anything else is printed
+			if !a.jsonOutput {
+				fmt.Println()
+			}
+			break
+		}
+		// Piped input isn't shown by the terminal, so print it after the prompt as if it had been typed
+		if config.EchoInput && !a.jsonOutput {
+			fmt.Println(userInput)
+		}
+		if a.handleCommand(userInput) {
+			continue
+		}
+
+		err = a.userTurn(ctx, userInput)
+	}
+
+	// Being interrupted mid-turn is a normal way to end the session rather than a failure
+	if errors.Is(err, context.Canceled) {
+		err = nil
+	}
+	if errors.Is(sessionCtx.Err(), context.DeadlineExceeded) {
+		a.commandPrompt(fmt.Sprintf("session timeout of %s reached", config.Timeout))
+	}
+
+	return errors.Join(err, a.finish())
+}
+
+// userTurn adds the user's message to the conversation and runs the turn answering it
+func (a *Agent) userTurn(ctx context.Context, text string) error {
+	a.turns++
+	a.emit(outputEvent{Type: "user_message", Text: text})
+
+	// convert user input to a message and append to conversation for contextual history or short term memory
+	userMessage := anthropic.NewUserMessage(anthropic.NewTextBlock(text))
+	a.conversation = append(a.conversation, userMessage)
+
+	err := a.runTurn(ctx)
+	// A failed request leaves the conversation intact, so let the user try again rather than lose the session
+	for err != nil && !errors.Is(err, ErrToolTurnLimit) && !errors.Is(err, context.Canceled) && a.offerRetry(ctx, err) {
+		err = a.runTurn(ctx)
+	}
+	if errors.Is(err, ErrToolTurnLimit) {
+		a.commandPrompt(err.Error())
+		return nil
+	}
+
+	return err
+}
+
+// offerRetry reports a failed turn and asks whether to retry it, edit the last message first or quit,
+// returning true when the turn should be run again
+func (a *Agent) offerRetry(ctx context.Context, turnErr error) bool {
 	for {
-		// Capture user input from the CLI, ignore for a tool response
-		if readUserInput {
-			a.requestPrompt()
-			userInput, ok := a.getUserMessage()
-			if !ok {
-				break
+		a.commandPrompt(fmt.Sprintf("Error: %v", turnErr))
+		a.questionPrompt("", "[r]etry, [e]dit your last message or [q]uit")
+		choice, ok := a.readUserMessage(ctx)
+		if !ok {
+			a.unansweredPrompt()
+			return false
+		}
+
+		switch strings.ToLower(strings.TrimSpace(choice)) {
+		case "r", "retry":
+			return true
+		case "e", "edit":
+			if a.editLastMessage(ctx) {
+				return true
 			}
+		case "q", "quit":
+			return false
+		}
+	}
+}
+
+// editLastMessage replaces the last message the user typed, dropping everything after it, and reports whether
+// a replacement was given
+func (a *Agent) editLastMessage(ctx context.Context) bool {
+	last := -1
+	for i, message := range a.conversation {
+		if message.Role == anthropic.MessageParamRoleUser && !isToolResultMessage(message) {
+			last = i
+		}
+	}
+	if last == -1 {
+		a.commandPrompt("there is no message to edit")
+		return false
+	}
+
+	a.questionPrompt("", "Replacement message")
+	text, ok := a.readUserMessage(ctx)
+	if !ok || strings.TrimSpace(text) == "" {
+		return false
+	}
+
+	a.conversation = append(a.conversation[:last], anthropic.NewUserMessage(anthropic.NewTextBlock(text)))
+	a.emit(outputEvent{Type: "user_message", Text: text})
+	return true
+}
+
+// readAnswer waits for the user's answer to a prompt, counting the wait as idle time
+func (a *Agent) readAnswer() (string, bool) {
+	stop := a.latency.measure(&a.latency.idle)
+	defer stop()
+
+	return a.getUserMessage()
+}
+
+// readUserMessage waits for the next line of user input, giving up when ctx is cancelled
+func (a *Agent) readUserMessage(ctx context.Context) (string, bool) {
+	type userMessage struct {
+		text string
+		ok   bool
+	}
+
+	stop := a.latency.measure(&a.latency.idle)
+	defer stop()
+
+	received := make(chan userMessage, 1)
+	go func() {
+		text, ok := a.getUserMessage()
+		received <- userMessage{text, ok}
+	}()
+
+	select {
+	case message := <-received:
+		return message.text, message.ok
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+// RunOnce sends a single prompt to Claude, resolves any tool calls and returns once Claude has answered
+func (a *Agent) RunOnce(ctx context.Context, prompt string) error {
+	a.emit(outputEvent{Type: "user_message", Text: prompt})
+	a.conversation = append(a.conversation, anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)))
+	a.turns++
+
+	err := a.runTurn(ctx)
+	return errors.Join(err, a.finish())
+}
+
+// finish runs once the conversation is over, however it ended, writing out anything configured to be saved and
+// summarising the session
+func (a *Agent) finish() error {
+	var err error
+	if config.Transcript != "" {
+		if writeErr := writeTranscript(config.Transcript, a.conversation, a.usage); writeErr != nil {
+			err = fmt.Errorf("failed to write transcript: %w", writeErr)
+		}
+	}
+	if config.Session != "" && !config.NoHistory {
+		if saveErr := saveSession(config.Session, a.conversation); saveErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to save session: %w", saveErr))
+		}
+	}
+	if config.ExportFormat == ExportOpenAI {
+		if exportErr := writeOpenAIExport(config.ExportPath, a.system, a.conversation); exportErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to export conversation: %w", exportErr))
+		}
+	}
+
+	a.summaryPrompt()
+
+	return err
+}
 
-			// convert user input to a message and append to conversation for contextual history or short term memory
-			userMessage := anthropic.NewUserMessage(anthropic.NewTextBlock(userInput))
-			conversation = append(conversation, userMessage)
+// Summary prompt describing the session once it has ended
+func (a *Agent) summaryPrompt() {
+	if a.jsonOutput {
+		a.emit(outputEvent{Type: "session_end", Text: a.latency.String(), InputTokens: a.usage.InputTokens, OutputTokens: a.usage.OutputTokens, ThinkingTokens: a.usage.ThinkingTokens})
+		return
+	}
+	if config.Quiet {
+		return
+	}
+	output := fmt.Sprintf("%d output tokens", a.usage.OutputTokens)
+	if a.usage.ThinkingTokens > 0 {
+		output += fmt.Sprintf(" (about %d of them thinking)", a.usage.ThinkingTokens)
+	}
+	fmt.Println(a.colorize(ANSI_DIM, fmt.Sprintf("Session ended after %d turns: %d input tokens, %s; %s", a.turns, a.usage.InputTokens, output, &a.latency)))
+}
+
+// runTurn runs inference on the conversation, executing requested tools and feeding their results back to Claude
+// until Claude responds without using a tool
+func (a *Agent) runTurn(ctx context.Context) error {
+	a.changes = nil
+	for toolTurns := 0; ; toolTurns++ {
+		// Stop a runaway tool loop, keeping its results so the user can choose to let Claude carry on
+		if config.MaxToolTurns > 0 && toolTurns > config.MaxToolTurns {
+			a.changesPrompt()
+			return fmt.Errorf("stopped after %d consecutive tool calls without user input, send a message to continue: %w", config.MaxToolTurns, ErrToolTurnLimit)
 		}
 
 		// Run inference with the updated conversation, ala send the conversation to Claude
-		message, err := a.runInference(ctx, conversation)
+		message, err := a.runInference(ctx, a.conversation)
 		if err != nil {
 			return err
 		}
 
+		// The API counts thinking as output without breaking it out, so its share is estimated from the text
+		var thinkingTokens int64
+		for _, content := range message.Content {
+			if content.Type == "thinking" {
+				thinkingTokens += int64(estimateTokens(content.Thinking))
+			}
+		}
+
+		a.usage.InputTokens += message.Usage.InputTokens
+		a.usage.OutputTokens += message.Usage.OutputTokens
+		a.usage.ThinkingTokens += thinkingTokens
+		a.contextTokens = message.Usage.InputTokens + message.Usage.OutputTokens
+
+		a.emit(outputEvent{
+			Type:           "usage",
+			Model:          string(message.Model),
+			InputTokens:    message.Usage.InputTokens,
+			OutputTokens:   message.Usage.OutputTokens,
+			ThinkingTokens: thinkingTokens,
+		})
+
 		// Append Claude's response to the conversation history
-		conversation = append(conversation, message.ToParam())
+		a.conversation = append(a.conversation, message.ToParam())
 
 		// Print out Claude's response to the CLI
 		toolResults := []anthropic.ContentBlockParamUnion{}
@@ -100,7 +601,22 @@ func (a *Agent) Run(ctx context.Context) error {
 			switch content.Type {
 			case "text":
 				a.responsePrompt(content.Text)
+			case "thinking":
+				// Thinking stays in the conversation through ToParam, as tool use after thinking requires it
+				a.thinkingPrompt(content.Thinking)
 			case "tool_use":
+				// Several changes needing approval are put to the user at once, after Claude's explanation of them
+				if a.approvals == nil && config.BatchApprove {
+					a.approvals = map[string]bool{}
+					if pending := a.pendingApprovals(message.Content); len(pending) > 1 {
+						a.approvals = a.approveBatch(pending)
+					}
+				}
+				// Every tool use needs a result, so calls over the limit are answered without being run
+				if config.MaxToolCalls > 0 && len(toolResults) >= config.MaxToolCalls {
+					toolResults = append(toolResults, anthropic.NewToolResultBlock(content.ID, fmt.Sprintf("not executed: only %d tool calls are run per message, prioritise the most important calls and make the rest in a later message", config.MaxToolCalls), true))
+					continue
+				}
 				result := a.executeTool(content.ID, content.Name, content.Input)
 				toolResults = append(toolResults, result)
 			default:
@@ -108,31 +624,87 @@ func (a *Agent) Run(ctx context.Context) error {
 			}
 		}
 
-		// If there is a tool result skip reading user input and append the tool result as a user message
+		a.approvals = nil
+
+		// Without a tool result the turn is over and it's the user's turn again
 		if len(toolResults) == 0 {
-			readUserInput = true
-			continue
+			a.changesPrompt()
+			return nil
 		}
-		readUserInput = false
-		conversation = append(conversation, anthropic.NewUserMessage(toolResults...))
-	}
 
-	return nil
+		// Append the tool result as a user message and go straight back to Claude
+		a.conversation = append(a.conversation, anthropic.NewUserMessage(toolResults...))
+	}
 }
 
 // Request prompt for user input
 func (a *Agent) requestPrompt() {
-	fmt.Printf("%sYou%s: ", ANSI_BLUE, ANSI_RESET)
+	if a.jsonOutput {
+		return
+	}
+	if !config.Quiet {
+		fmt.Println(a.colorize(ANSI_DIM, formatContextStatus(a.contextTokens, config.ContextWindow)))
+	}
+	fmt.Printf("%s: ", a.colorize(config.UserColor, config.UserLabel))
+}
+
+// Question prompt asking the user something, shown after details when there are any. With --output json it is
+// a question event instead, so stdout stays a stream of JSON lines.
+func (a *Agent) questionPrompt(details, question string) {
+	if a.jsonOutput {
+		if details != "" {
+			question = details + "\n" + question
+		}
+		a.emit(outputEvent{Type: "question", Text: question})
+		return
+	}
+	if details != "" {
+		fmt.Println(a.colorize(ANSI_DIM, details))
+	}
+	fmt.Printf("%s: ", a.colorize(config.UserColor, question))
+}
+
+// unansweredPrompt ends the line of a question the input ended without answering
+func (a *Agent) unansweredPrompt() {
+	if !a.jsonOutput {
+		fmt.Println()
+	}
 }
 
 // Response prompt for Claude's output
 func (a *Agent) responsePrompt(response string) {
-	fmt.Printf("%sClaude%s: %s\n", ANSI_YELLOW, ANSI_RESET, response)
+	if a.jsonOutput {
+		a.emit(outputEvent{Type: "assistant_text", Text: response})
+		return
+	}
+	fmt.Printf("%s: %s\n", a.colorize(config.AssistantColor, config.AssistantLabel), wrapText(response, a.width, utf8.RuneCountInString(config.AssistantLabel)+2))
+}
+
+// Thinking prompt for Claude's extended thinking, shown dimmed so it reads as an aside
+func (a *Agent) thinkingPrompt(thinking string) {
+	if a.jsonOutput {
+		a.emit(outputEvent{Type: "thinking", Text: thinking})
+		return
+	}
+	if config.Quiet {
+		return
+	}
+	fmt.Println(a.colorize(ANSI_DIM, config.AssistantLabel+" is thinking: "+thinking))
+}
+
+// colorize wraps text in the given ANSI color when writing to a terminal, leaving it plain otherwise
+func (a *Agent) colorize(color, text string) string {
+	if !a.colors || color == "" {
+		return text
+	}
+
+	return color + text + ANSI_RESET
 }
 
 // runInference sends the conversation history with registered tooling to Claude and returns the response
 func (a *Agent) runInference(ctx context.Context, conversation []anthropic.MessageParam) (*anthropic.Message, error) {
-	anthropicTools := []anthropic.ToolUnionParam{}
+	// Without tools the field is left out entirely, so plain chat requests carry no tool machinery
+	var anthropicTools []anthropic.ToolUnionParam
 	for _, tool := range a.tools {
 		anthropicTools = append(anthropicTools, anthropic.ToolUnionParam{
 			OfTool: &anthropic.ToolParam{
@@ -143,15 +715,140 @@ func (a *Agent) runInference(ctx context.Context, conversation []anthropic.Messa
 		})
 	}
 
-	return a.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.ModelClaude3_7SonnetLatest,
-		MaxTokens: int64(1024),
+	// An overloaded model, once the client has used up its own retries, hands the request on to the next fallback
+	params := a.inferenceParams(conversation, anthropicTools)
+	var message *anthropic.Message
+	var err error
+	for i, model := range a.models() {
+		if i > 0 {
+			a.commandPrompt(fmt.Sprintf("%s is overloaded, falling back to %s", params.Model, model))
+		}
+
+		// Wait for a free slot rather than letting a burst of turns trip the API's rate limit
+		if a.limiter != nil {
+			if err := a.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		params.Model = model
+		stop := a.startProgress()
+		stopTimer := a.latency.measure(&a.latency.inference)
+		message, err = a.client.Messages.New(ctx, params, a.requestOptions()...)
+		stopTimer()
+		stop()
+		if !isOverloaded(err) {
+			break
+		}
+	}
+
+	return message, err
+}
+
+// requestOptions are the per-request options, such as beta headers, that the flags call for
+func (a *Agent) requestOptions() []option.RequestOption {
+	var opts []option.RequestOption
+	if config.BetaContext1M {
+		opts = append(opts, option.WithHeaderAdd("anthropic-beta", string(anthropic.AnthropicBetaContext1m2025_08_07)))
+	}
+
+	return opts
+}
+
+// models lists the model to use followed by the --model-fallback models to try in turn when it is overloaded
+func (a *Agent) models() []anthropic.Model {
+	models := []anthropic.Model{a.model}
+	for _, fallback := range config.ModelFallbacks {
+		if !slices.Contains(models, anthropic.Model(fallback)) {
+			models = append(models, anthropic.Model(fallback))
+		}
+	}
+
+	return models
+}
+
+// isOverloaded reports whether err is the API saying the model is too busy to serve the request
+func isOverloaded(err error) bool {
+	var apiErr *anthropic.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == 529
+}
+
+// inferenceParams builds the request for the conversation from the agent's current settings
+func (a *Agent) inferenceParams(conversation []anthropic.MessageParam, tools []anthropic.ToolUnionParam) anthropic.MessageNewParams {
+	params := anthropic.MessageNewParams{
+		Model:     a.model,
+		MaxTokens: defaultMaxTokens,
+		System:    a.systemBlocks(),
 		Messages:  conversation,
-		Tools:     anthropicTools,
-	})
+		Tools:     tools,
+	}
+	if a.temperature != nil {
+		params.Temperature = anthropic.Float(*a.temperature)
+	}
+	if len(config.StopSequences) > 0 {
+		params.StopSequences = config.StopSequences
+	}
+	if config.Thinking > 0 {
+		// The thinking budget counts towards max_tokens, so leave the usual room for the answer on top of it
+		params.Thinking = anthropic.ThinkingConfigParamOfEnabled(config.Thinking)
+		params.MaxTokens += config.Thinking
+	}
+	if config.MaxTokens > 0 {
+		params.MaxTokens = config.MaxTokens
+	}
+
+	return params
 }
 
+// systemBlocks returns the system prompt sent with every request, if there is one
+func (a *Agent) systemBlocks() []anthropic.TextBlockParam {
+	if a.system == "" {
+		return nil
+	}
+
+	return []anthropic.TextBlockParam{{Text: a.system}}
+}
+
+// executeTool runs the named tool with Claude's input and wraps the outcome in a tool result block
 func (a *Agent) executeTool(id, name string, input json.RawMessage) anthropic.ContentBlockParamUnion {
+	// Only the tool itself is timed by runTool, not the prompts around it that wait on the user
+	before := a.latency.tools
+	content, isError := a.runTool(id, name, input).content()
+	duration := a.latency.tools - before
+	a.toolDurationPrompt(name, duration)
+
+	// Odd files and command output can hold invalid UTF-8, which can't be sent to Claude as is
+	if !utf8.ValidString(content) {
+		content = strings.ToValidUTF8(content, "\uFFFD") + "\n\n(invalid UTF-8 in this result was replaced with U+FFFD)"
+	}
+
+	if config.ReviewResults {
+		content = a.reviewToolResult(name, content)
+	}
+	a.emit(outputEvent{Type: "tool_result", ID: id, Name: name, Text: content, IsError: isError, DurationMS: duration.Milliseconds()})
+
+	return anthropic.NewToolResultBlock(id, content, isError)
+}
+
+// reviewToolResult shows the user a tool's result and lets them attach a note for Claude, such as a correction,
+// before it is sent back
+func (a *Agent) reviewToolResult(name, content string) string {
+	preview := content
+	if lines := strings.Split(preview, "\n"); len(lines) > reviewPreviewLines {
+		preview = strings.Join(lines[:reviewPreviewLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-reviewPreviewLines)
+	}
+
+	a.questionPrompt(preview, "Note on "+name+" result (enter to skip)")
+	note, ok := a.readAnswer()
+	if !ok || strings.TrimSpace(note) == "" {
+		return content
+	}
+
+	return fmt.Sprintf("%s\n\nNote from the user about this result: %s", content, strings.TrimSpace(note))
+}
+
+// runTool looks up and calls the named tool, returning its result
+func (a *Agent) runTool(id, name string, input json.RawMessage) ToolResult {
 	var toolDef ToolDefinition
 	var found bool
 	for _, tool := range a.tools {
@@ -162,15 +859,188 @@ func (a *Agent) executeTool(id, name string, input json.RawMessage) anthropic.Co
 		}
 	}
 	if !found {
-		return anthropic.NewToolResultBlock(id, "tool not found", true)
+		return toolError("tool not found")
+	}
+
+	a.toolPrompt(id, name, input)
+	if err := validateToolInput(toolDef.InputSchema, input); errors.Is(err, ErrInvalidJSON) {
+		return toolError(fmt.Sprintf("%s was not run: %s", name, err))
+	} else if err != nil {
+		return toolError(fmt.Sprintf("input does not match the %s schema: %s", name, err))
+	}
+
+	// Protected files are refused before any approval so not even an allow policy can change them
+	var targets []string
+	if toolDef.Mutates {
+		// Without knowing what a call changes it can't be checked, so it isn't run
+		var err error
+		targets, err = toolTargets(toolDef, input)
+		if err != nil {
+			return toolError(err.Error())
+		}
+		for _, target := range targets {
+			if pattern := protectedPattern(target); pattern != "" {
+				return toolError(protectedError(target, pattern).Error())
+			}
+		}
+	}
+
+	switch toolPolicy(name) {
+	case PolicyDeny:
+		return toolError(fmt.Sprintf("the %s tool is denied by the user's policy", name))
+	case PolicyAsk:
+		approved, decided := a.approvals[id]
+		if !decided {
+			approved = a.approveTool(name)
+		}
+		if !approved {
+			return toolError(fmt.Sprintf("the user declined to run %s", name))
+		}
+	}
+
+	if config.GitCheck && toolDef.Mutates {
+		var warnings []string
+		for _, target := range targets {
+			if warning := uncommittedWarning(target); warning != "" {
+				warnings = append(warnings, warning)
+			}
+		}
+		if warning := strings.Join(warnings, "; "); warning != "" && !a.confirmEdit(warning) {
+			return toolError(fmt.Sprintf("the user declined to run %s: %s", name, warning))
+		}
+	}
+
+	existed := map[string]bool{}
+	for _, target := range targets {
+		existed[target] = pathExists(target)
+	}
+
+	stop := a.latency.measure(&a.latency.tools)
+	result := a.callToolWithRetries(toolDef, input)
+	stop()
+	if result.Status != ToolError {
+		for _, target := range targets {
+			a.recordChange(target, existed[target])
+		}
+	}
+	return result
+}
+
+// callToolWithRetries runs the tool, retrying transient failures as many times as the tool allows
+func (a *Agent) callToolWithRetries(toolDef ToolDefinition, input json.RawMessage) ToolResult {
+	result, err := a.callToolWithTimeout(toolDef, input)
+	for attempt := 0; attempt < toolDef.Retries && err != nil && isRetryable(err); attempt++ {
+		time.Sleep(toolRetryDelay)
+		result, err = a.callToolWithTimeout(toolDef, input)
+	}
+	if err != nil {
+		return toolError(err.Error())
+	}
+
+	return result
+}
+
+// callToolWithTimeout runs the tool, giving up once its timeout, or the global default when it has none, expires.
+// Tools don't take a context, so a tool that overruns is left to finish in the background and its result dropped.
+// That would let a tool that changes files keep writing after Claude has been told it failed, so those always run
+// to completion.
+func (a *Agent) callToolWithTimeout(toolDef ToolDefinition, input json.RawMessage) (ToolResult, error) {
+	timeout := toolDef.Timeout
+	if timeout == 0 {
+		timeout = config.ToolTimeout
+	}
+	if timeout <= 0 || toolDef.Mutates {
+		return a.callTool(toolDef, input)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type toolOutcome struct {
+		result ToolResult
+		err    error
+	}
+	done := make(chan toolOutcome, 1)
+	go func() {
+		result, err := a.callTool(toolDef, input)
+		done <- toolOutcome{result, err}
+	}()
+
+	select {
+	case outcome := <-done:
+		return outcome.result, outcome.err
+	case <-ctx.Done():
+		return ToolResult{}, fmt.Errorf("tool %s did not finish within %s: %w", toolDef.Name, timeout, ErrTimeout)
+	}
+}
+
+// callTool invokes the tool, streaming its progress to the terminal when it supports that. What is streamed is
+// only for the user; Claude receives the returned result as usual. An error is returned only when the tool
+// failed outright, so it can be retried; a ToolResult reporting an error is the tool's considered answer.
+func (a *Agent) callTool(toolDef ToolDefinition, input json.RawMessage) (ToolResult, error) {
+	if toolDef.ResultFunction != nil {
+		return toolDef.ResultFunction(input)
 	}
 
-	fmt.Printf("%stool%s: %s(%s)\n", ANSI_GREEN, ANSI_RESET, name, input)
-	response, err := toolDef.Function(input)
+	var response string
+	var err error
+	if toolDef.StreamFunction == nil || a.jsonOutput || config.Quiet {
+		response, err = toolDef.Function(input)
+	} else {
+		if a.colors {
+			fmt.Print(ANSI_DIM)
+			defer fmt.Print(ANSI_RESET)
+		}
+		response, err = toolDef.StreamFunction(input, os.Stdout)
+	}
 	if err != nil {
-		return anthropic.NewToolResultBlock(id, err.Error(), true)
+		return ToolResult{}, err
+	}
+
+	return toolSuccess(response), nil
+}
+
+// Tool prompt logging each tool call
+func (a *Agent) toolPrompt(id, name string, input json.RawMessage) {
+	if a.jsonOutput {
+		a.emit(outputEvent{Type: "tool_call", ID: id, Name: name, Input: input})
+		return
+	}
+	if config.Quiet {
+		return
+	}
+	fmt.Printf("%s: %s(%s)\n", a.colorize(config.ToolColor, config.ToolLabel), name, redactSecrets(formatToolInput(input)))
+}
+
+// formatToolInput renders tool input for the log line, indented with --json-pretty and compact otherwise
+func formatToolInput(input json.RawMessage) string {
+	if !config.JSONPretty {
+		return string(input)
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, input, "", "  "); err != nil {
+		return string(input)
+	}
+
+	return indented.String()
+}
+
+// Tool duration prompt reporting how long a tool call took, printed once it finishes as its output may come first
+func (a *Agent) toolDurationPrompt(name string, duration time.Duration) {
+	if a.jsonOutput || config.Quiet {
+		return
 	}
-	return anthropic.NewToolResultBlock(id, response, false)
+	fmt.Println(a.colorize(ANSI_DIM, fmt.Sprintf("%s: %s finished [%s]", config.ToolLabel, name, formatDuration(duration))))
+}
+
+// formatDuration rounds a duration for display, to milliseconds under a second and tenths of a second above
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+
+	return d.Round(100 * time.Millisecond).String()
 }
 
 type ToolDefinition struct {
@@ -178,6 +1048,19 @@ type ToolDefinition struct {
 	Description string                         `json:"description"`
 	InputSchema anthropic.ToolInputSchemaParam `json:"input_schema"`
 	Function    func(input json.RawMessage) (string, error)
+	// ResultFunction, when set, is called instead of Function by tools that report a ToolResult
+	ResultFunction func(input json.RawMessage) (ToolResult, error)
+	// StreamFunction optionally runs the tool while writing its progress to output as it happens
+	StreamFunction func(input json.RawMessage, output io.Writer) (string, error)
+	// Timeout limits how long the tool may run, zero falls back to the global --tool-timeout. Tools that mutate
+	// files aren't cut off.
+	Timeout time.Duration
+	// Retries is how many more times a transient failure is attempted, only safe for idempotent tools
+	Retries int
+	// Mutates marks tools that change files, the one named by their path input unless Targets is set
+	Mutates bool
+	// Targets lists the files a call would change, for mutating tools that change files other than their path input
+	Targets func(input json.RawMessage) ([]string, error)
 }
 
 var ReadFileDefinition = ToolDefinition{
@@ -185,10 +1068,12 @@ var ReadFileDefinition = ToolDefinition{
 	Description: "Read the contents of a given relative file path. Use this when you want to see what's inside a file. Do not use this with directory names.",
 	InputSchema: ReadFileInputSchema,
 	Function:    ReadFile,
+	Retries:     readToolRetries,
 }
 
 type ReadFileInput struct {
-	Path string `json:"path" jsonschema_description:"The relative path of a file in the working directory."`
+	Path       string `json:"path" jsonschema_description:"The relative path of a file in the working directory."`
+	AllowLarge bool   `json:"allow_large,omitempty" jsonschema_description:"Read the file even if it exceeds the maximum file size. Only use this when the whole file is really needed."`
 }
 
 var ReadFileInputSchema = GenerateSchema[ReadFileInput]()
@@ -200,12 +1085,17 @@ func ReadFile(input json.RawMessage) (string, error) {
 		panic(err)
 	}
 
-	content, err := os.ReadFile(readFileInput.Path)
+	resolved, err := resolvePath(readFileInput.Path)
 	if err != nil {
 		return "", err
 	}
 
-	return string(content), nil
+	content, err := readTextFile(resolved, readFileInput.AllowLarge)
+	if err != nil {
+		return "", withPathSuggestions(readFileInput.Path, err)
+	}
+
+	return content, nil
 }
 
 // GenerateSchema generates a JSON schema for a given type T and returns it as a ToolInputSchemaParam
@@ -220,22 +1110,33 @@ func GenerateSchema[T any]() anthropic.ToolInputSchemaParam {
 
 	return anthropic.ToolInputSchemaParam{
 		Properties: schema.Properties,
+		Required:   schema.Required,
 	}
 }
 
 var ListFilesDefinition = ToolDefinition{
 	Name:        "list_files",
-	Description: "List files and directories at a given path. If no path is provided, lists files in the current directory.",
+	Description: "List files and directories at a given path. If no path is provided, lists files in the current directory. Hidden files and directories, such as .git, are left out unless include_hidden is set.",
 	InputSchema: ListFilesInputSchema,
 	Function:    ListFiles,
+	Retries:     readToolRetries,
 }
 
 type ListFilesInput struct {
-	Path string `json:"path,omitempty" jsonschema_description:"Optional relative path to list files from. Defaults to current directory if not provided."`
+	Path          string `json:"path,omitempty" jsonschema_description:"Optional relative path to list files from. Defaults to current directory if not provided."`
+	IncludeHidden bool   `json:"include_hidden,omitempty" jsonschema_description:"Also list files and directories whose names start with a dot."`
+	WithMetadata  bool   `json:"with_metadata,omitempty" jsonschema_description:"Return each entry as an object with path, size in bytes and modified_time instead of a bare path. Useful for spotting recently changed files."`
 }
 
 var ListFilesInputSchema = GenerateSchema[ListFilesInput]()
 
+// fileEntry is a list_files entry when with_metadata is set
+type fileEntry struct {
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modified_time"`
+}
+
 func ListFiles(input json.RawMessage) (string, error) {
 	listFilesInput := ListFilesInput{}
 	err := json.Unmarshal(input, &listFilesInput)
@@ -243,12 +1144,15 @@ func ListFiles(input json.RawMessage) (string, error) {
 		panic(err)
 	}
 
-	dir := "."
-	if listFilesInput.Path != "" {
-		dir = listFilesInput.Path
+	dir, err := resolvePath(listFilesInput.Path)
+	if err != nil {
+		return "", err
 	}
 
+	includeHidden := config.IncludeHidden || listFilesInput.IncludeHidden
+
 	var files []string
+	var entries []fileEntry
 	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -259,12 +1163,20 @@ func ListFiles(input json.RawMessage) (string, error) {
 			return err
 		}
 
+		hidden := relPath != "." && strings.HasPrefix(info.Name(), ".") && !includeHidden
+		if hidden || agentIgnored(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		if relPath != "." {
 			if info.IsDir() {
-				files = append(files, relPath+"/")
-			} else {
-				files = append(files, relPath)
+				relPath += "/"
 			}
+			files = append(files, relPath)
+			entries = append(entries, fileEntry{Path: relPath, Size: info.Size(), ModifiedTime: info.ModTime().UTC().Truncate(time.Second)})
 		}
 		return nil
 	})
@@ -273,7 +1185,12 @@ func ListFiles(input json.RawMessage) (string, error) {
 		return "", err
 	}
 
-	result, err := json.Marshal(files)
+	var result []byte
+	if listFilesInput.WithMetadata {
+		result, err = json.Marshal(entries)
+	} else {
+		result, err = json.Marshal(files)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -287,16 +1204,70 @@ var EditFileDefinition = ToolDefinition{
 
 Replaces 'old_str' with 'new_str' in the given file. 'old_str' and 'new_str' MUST be different from each other.
 
+'old_str' must match exactly once. If it legitimately appears several times, set 'occurrence' to the 1-based match to replace, or to "all" to replace every match.
+
+When the exact text is fragile, for example because of whitespace, set 'old_regexp' to a Go regular expression instead of 'old_str'. It must match exactly once and the match is replaced by 'new_str' literally.
+
 If the file specified with path doesn't exist, it will be created.
 `,
 	InputSchema: EditFileInputSchema,
 	Function:    EditFile,
+	Mutates:     true,
 }
 
 type EditFileInput struct {
-	Path   string `json:"path" jsonschema_description:"The path to the file"`
-	OldStr string `json:"old_str" jsonschema_description:"Text to search for - must match exactly and must only have one match exactly"`
-	NewStr string `json:"new_str" jsonschema_description:"Text to replace old_str with"`
+	Path           string     `json:"path" jsonschema_description:"The path to the file"`
+	OldStr         string     `json:"old_str,omitempty" jsonschema_description:"Text to search for - must match exactly and must only have one match exactly"`
+	OldRegexp      string     `json:"old_regexp,omitempty" jsonschema_description:"A Go regular expression to search for instead of old_str - must only have one match exactly"`
+	NewStr         string     `json:"new_str" jsonschema_description:"Text to replace old_str with"`
+	Occurrence     Occurrence `json:"occurrence,omitempty" jsonschema_description:"Optional 1-based index of the match of old_str to replace, or \"all\" to replace every match"`
+	ExpectedSHA256 string     `json:"expected_sha256,omitempty" jsonschema_description:"Optional SHA-256 of the file as last read, from file_hash. The edit is refused if the file has changed since."`
+}
+
+// OccurrenceAll selects every match of old_str
+const OccurrenceAll Occurrence = -1
+
+// Occurrence selects which match of old_str an edit applies to, where zero means old_str must be unique
+type Occurrence int
+
+// UnmarshalJSON accepts a positive number, a numeric string or "all"
+func (o *Occurrence) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	var n int
+	switch v := value.(type) {
+	case float64:
+		n = int(v)
+		if float64(n) != v {
+			n = 0
+		}
+	case string:
+		if v == "all" {
+			*o = OccurrenceAll
+			return nil
+		}
+		n, _ = strconv.Atoi(v)
+	}
+
+	if n < 1 {
+		return fmt.Errorf("occurrence must be a positive number or \"all\", got %s: %w", data, ErrInvalidInput)
+	}
+	*o = Occurrence(n)
+
+	return nil
+}
+
+// JSONSchema describes the accepted occurrence values to Claude
+func (Occurrence) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{Type: "integer", Minimum: json.Number("1")},
+			{Type: "string", Enum: []any{"all"}},
+		},
+	}
 }
 
 var EditFileInputSchema = GenerateSchema[EditFileInput]()
@@ -308,31 +1279,111 @@ func EditFile(input json.RawMessage) (string, error) {
 		return "", err
 	}
 
-	if editFileInput.Path == "" || editFileInput.OldStr == editFileInput.NewStr {
-		return "", fmt.Errorf("invalid input parameters")
+	useRegexp := editFileInput.OldRegexp != ""
+	if editFileInput.Path == "" || (!useRegexp && editFileInput.OldStr == editFileInput.NewStr) {
+		return "", fmt.Errorf("invalid input parameters: %w", ErrInvalidInput)
+	}
+	if useRegexp && (editFileInput.OldStr != "" || editFileInput.Occurrence != 0) {
+		return "", fmt.Errorf("old_regexp can't be combined with old_str or occurrence: %w", ErrInvalidInput)
 	}
 
-	content, err := os.ReadFile(editFileInput.Path)
+	filePath, err := resolvePath(editFileInput.Path)
 	if err != nil {
-		if os.IsNotExist(err) && editFileInput.OldStr == "" {
-			return createNewFile(editFileInput.Path, editFileInput.NewStr)
-		}
 		return "", err
 	}
 
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) && editFileInput.OldStr == "" && !useRegexp && editFileInput.ExpectedSHA256 == "" {
+			return createNewFile(filePath, editFileInput.NewStr)
+		}
+		return "", withPathSuggestions(editFileInput.Path, err)
+	}
+
+	if editFileInput.ExpectedSHA256 != "" {
+		sum := sha256.Sum256(content)
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, editFileInput.ExpectedSHA256) {
+			return "", fmt.Errorf("%s has changed since it was read (sha256 is now %s), read it again before editing: %w", editFileInput.Path, actual, ErrConflict)
+		}
+	}
+
 	oldContent := string(content)
-	newContent := strings.Replace(oldContent, editFileInput.OldStr, editFileInput.NewStr, -1)
+	var newContent string
+	if useRegexp {
+		newContent, err = replaceRegexpMatch(oldContent, editFileInput.OldRegexp, editFileInput.NewStr)
+	} else {
+		// A repeated edit finds new_str where old_str used to be, so report it as done rather than not found
+		if editFileInput.OldStr != "" && editFileInput.NewStr != "" &&
+			!strings.Contains(oldContent, editFileInput.OldStr) && strings.Contains(oldContent, editFileInput.NewStr) {
+			return "no change needed; new_str already present", nil
+		}
+		newContent, err = replaceOccurrence(oldContent, editFileInput.OldStr, editFileInput.NewStr, editFileInput.Occurrence)
+	}
+	if err != nil {
+		return "", err
+	}
 
-	if oldContent == newContent && editFileInput.OldStr != "" {
-		return "", fmt.Errorf("old_str not found in file")
+	err = backupFile(filePath)
+	if err != nil {
+		return "", err
 	}
 
-	err = os.WriteFile(editFileInput.Path, []byte(newContent), 0644)
+	formatted, note := autoFormat(filePath, []byte(newContent))
+	err = os.WriteFile(filePath, formatted, 0644)
 	if err != nil {
 		return "", err
 	}
 
-	return "OK", nil
+	return "OK" + note, nil
+}
+
+// replaceOccurrence replaces the selected match of oldStr with newStr. Without an occurrence oldStr must match
+// exactly once, so an ambiguous edit is refused rather than applied to the wrong place.
+func replaceOccurrence(content, oldStr, newStr string, occurrence Occurrence) (string, error) {
+	// An empty old_str only creates files, it matches everywhere in an existing one
+	if oldStr == "" {
+		return "", fmt.Errorf("old_str must not be empty when editing an existing file, use replace_file to overwrite it: %w", ErrInvalidInput)
+	}
+
+	count := strings.Count(content, oldStr)
+	switch {
+	case count == 0:
+		return "", fmt.Errorf("old_str not found in file: %w", ErrNotFound)
+	case occurrence == OccurrenceAll:
+		return strings.ReplaceAll(content, oldStr, newStr), nil
+	case occurrence == 0 && count > 1:
+		return "", fmt.Errorf("old_str matches %d times; include more surrounding text to make it unique, or set occurrence: %w", count, ErrMultipleMatches)
+	case int(occurrence) > count:
+		return "", fmt.Errorf("occurrence %d requested but old_str only matches %d times: %w", occurrence, count, ErrInvalidInput)
+	}
+
+	// Skip past the earlier matches to the start of the selected one
+	index := 0
+	for i := 1; i < max(int(occurrence), 1); i++ {
+		index += strings.Index(content[index:], oldStr) + len(oldStr)
+	}
+	index += strings.Index(content[index:], oldStr)
+
+	return content[:index] + newStr + content[index+len(oldStr):], nil
+}
+
+// replaceRegexpMatch replaces the single match of pattern with newStr taken literally, refusing patterns that
+// match more than once just like old_str
+func replaceRegexpMatch(content, pattern, newStr string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid old_regexp: %v: %w", err, ErrInvalidInput)
+	}
+
+	matches := re.FindAllStringIndex(content, 2)
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("old_regexp not found in file: %w", ErrNotFound)
+	case 2:
+		return "", fmt.Errorf("old_regexp matches more than once; make the pattern more specific: %w", ErrMultipleMatches)
+	}
+
+	return content[:matches[0][0]] + newStr + content[matches[0][1]:], nil
 }
 
 func createNewFile(filePath, content string) (string, error) {
@@ -344,10 +1395,11 @@ func createNewFile(filePath, content string) (string, error) {
 		}
 	}
 
-	err := os.WriteFile(filePath, []byte(content), 0644)
+	formatted, note := autoFormat(filePath, []byte(content))
+	err := os.WriteFile(filePath, formatted, 0644)
 	if err != nil {
 		return "", fmt.Errorf("failed to create file: %w", err)
 	}
 
-	return fmt.Sprintf("Successfully created file %s", filePath), nil
+	return fmt.Sprintf("Successfully created file %s%s", filePath, note), nil
 }
@@ -0,0 +1,1442 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// setupWorkspace runs the test in a fresh temporary directory with the default configuration, restoring the
+// package state tools share once the test finishes
+func setupWorkspace(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	savedConfig, savedWorkDir, savedIgnore := config, workDir, agentIgnore
+	config, workDir, agentIgnore = DefaultConfig(), ".", nil
+	t.Cleanup(func() {
+		config, workDir, agentIgnore = savedConfig, savedWorkDir, savedIgnore
+	})
+
+	return dir
+}
+
+// writeFile creates a file in the workspace, along with any missing parent directories
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readFile returns the content of a workspace file
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(content)
+}
+
+// callTool marshals input and passes it to a tool function
+func callTool(t *testing.T, function func(json.RawMessage) (string, error), input any) (string, error) {
+	t.Helper()
+
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return function(encoded)
+}
+
+// captureStdout returns what run prints to stdout
+func captureStdout(t *testing.T, run func()) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := os.Stdout
+	os.Stdout = writer
+	captured := make(chan string)
+	go func() {
+		output, _ := io.ReadAll(reader)
+		captured <- string(output)
+	}()
+
+	defer func() {
+		os.Stdout = saved
+	}()
+	run()
+	writer.Close()
+
+	return <-captured
+}
+
+// fakeAPI serves the Messages API, answering each request with the message JSON reply returns for its decoded body
+func fakeAPI(t *testing.T, reply func(request map[string]any) string) *anthropic.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, reply(request))
+	}))
+	t.Cleanup(server.Close)
+
+	client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	return &client
+}
+
+// messageJSON builds an API response message holding the given content blocks
+func messageJSON(blocks ...string) string {
+	stopReason := "end_turn"
+	for _, block := range blocks {
+		if strings.Contains(block, `"type":"tool_use"`) {
+			stopReason = "tool_use"
+		}
+	}
+
+	return fmt.Sprintf(`{"id":"msg","type":"message","role":"assistant","model":"test-model","content":[%s],"stop_reason":%q,"usage":{"input_tokens":10,"output_tokens":5}}`,
+		strings.Join(blocks, ","), stopReason)
+}
+
+func textBlock(text string) string {
+	encoded, _ := json.Marshal(text)
+	return fmt.Sprintf(`{"type":"text","text":%s}`, encoded)
+}
+
+func toolUseBlock(id, name, input string) string {
+	return fmt.Sprintf(`{"type":"tool_use","id":%q,"name":%q,"input":%s}`, id, name, input)
+}
+
+// scriptedInput returns a getUserMessage that replays lines and then reports the end of input
+func scriptedInput(lines ...string) func() (string, bool) {
+	return func() (string, bool) {
+		if len(lines) == 0 {
+			return "", false
+		}
+		line := lines[0]
+		lines = lines[1:]
+		return line, true
+	}
+}
+
+// newTestAgent creates an agent talking to client that reads its input from lines
+func newTestAgent(client *anthropic.Client, tools []ToolDefinition, lines ...string) *Agent {
+	return NewAgent(client, scriptedInput(lines...), tools)
+}
+
+func TestReadFileSizeLimit(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxFileSize = 10
+	writeFile(t, "small.txt", "small")
+	writeFile(t, "large.txt", "much larger than ten bytes")
+
+	got, err := callTool(t, ReadFile, map[string]any{"path": "small.txt"})
+	if err != nil || got != "small" {
+		t.Errorf("small file: got %q, %v", got, err)
+	}
+
+	_, err = callTool(t, ReadFile, map[string]any{"path": "large.txt"})
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("large file: got error %v, want ErrFileTooLarge", err)
+	}
+
+	got, err = callTool(t, ReadFile, map[string]any{"path": "large.txt", "allow_large": true})
+	if err != nil || got != "much larger than ten bytes" {
+		t.Errorf("large file with allow_large: got %q, %v", got, err)
+	}
+}
+
+// toolThenText replies to the first request with a call to tool and to the next with text, recording the
+// tool result Claude was sent
+func toolThenText(name, input, text string, toolResult *string) func(map[string]any) string {
+	return func(request map[string]any) string {
+		messages := request["messages"].([]any)
+		last := messages[len(messages)-1].(map[string]any)
+		for _, block := range last["content"].([]any) {
+			if block := block.(map[string]any); block["type"] == "tool_result" {
+				if toolResult != nil {
+					encoded, _ := json.Marshal(block["content"])
+					*toolResult = string(encoded)
+				}
+				return messageJSON(textBlock(text))
+			}
+		}
+
+		return messageJSON(toolUseBlock("tool_1", name, input))
+	}
+}
+
+func TestRunOnceResolvesToolCalls(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "greeting.txt", "hello")
+
+	var toolResult string
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "The file says hello", &toolResult))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "What does greeting.txt say?"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if !strings.Contains(toolResult, "hello") {
+		t.Errorf("tool result sent to Claude = %s, want the file content", toolResult)
+	}
+	if !strings.Contains(output, "The file says hello") {
+		t.Errorf("output %q is missing the final answer", output)
+	}
+	if strings.Contains(output, "\x1b[") {
+		t.Errorf("output %q is colorized although stdout is not a terminal", output)
+	}
+}
+
+func TestCheckAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "api key set", env: map[string]string{"ANTHROPIC_API_KEY": "sk-ant-test"}},
+		{name: "auth token set", env: map[string]string{"ANTHROPIC_AUTH_TOKEN": "token"}},
+		{name: "unset", env: map[string]string{}, wantErr: true},
+		{name: "blank", env: map[string]string{"ANTHROPIC_API_KEY": "  "}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkAPIKey(func(key string) string { return test.env[key] })
+			if (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestEditFileOccurrence(t *testing.T) {
+	tests := []struct {
+		name       string
+		occurrence any
+		want       string
+	}{
+		{name: "second of three", occurrence: 2, want: "foo bar foo\n"},
+		{name: "all", occurrence: "all", want: "bar bar bar\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "file.txt", "foo foo foo\n")
+
+			_, err := callTool(t, EditFile, map[string]any{"path": "file.txt", "old_str": "foo", "new_str": "bar", "occurrence": test.occurrence})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readFile(t, "file.txt"); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEditFileOccurrenceOutOfRange(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", "foo foo foo\n")
+
+	_, err := callTool(t, EditFile, map[string]any{"path": "file.txt", "old_str": "foo", "new_str": "bar", "occurrence": 4})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("got error %v, want ErrInvalidInput", err)
+	}
+	if got := readFile(t, "file.txt"); got != "foo foo foo\n" {
+		t.Errorf("file changed to %q", got)
+	}
+}
+
+func TestEditFileEmptyOldStr(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", "abc\n")
+
+	_, err := callTool(t, EditFile, map[string]any{"path": "file.txt", "old_str": "", "new_str": "x"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("got error %v, want ErrInvalidInput", err)
+	}
+	if got := readFile(t, "file.txt"); got != "abc\n" {
+		t.Errorf("file changed to %q", got)
+	}
+
+	_, err = callTool(t, EditFile, map[string]any{"path": "new.txt", "old_str": "", "new_str": "created\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, "new.txt"); got != "created\n" {
+		t.Errorf("new file has %q", got)
+	}
+}
+
+func TestCustomPromptLabels(t *testing.T) {
+	setupWorkspace(t)
+	config.UserLabel = "Me"
+	config.AssistantLabel = "Bot"
+	config.ToolLabel = "action"
+	config.ToolColor = ANSI_YELLOW
+	writeFile(t, "greeting.txt", "hello")
+
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "It says hello", nil))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition}, "Read greeting.txt")
+	agent.colors = true
+
+	output := captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	for _, want := range []string{
+		agent.colorize(config.UserColor, "Me") + ": ",
+		agent.colorize(ANSI_YELLOW, "action") + ": read_file(",
+		agent.colorize(config.AssistantColor, "Bot") + ": It says hello",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output is missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "Claude:") || strings.Contains(output, "You:") {
+		t.Errorf("output still has a default label:\n%s", output)
+	}
+}
+
+func TestStreamFunctionOutputIsOnlyShown(t *testing.T) {
+	setupWorkspace(t)
+
+	streamed := ToolDefinition{
+		Name: "streamed",
+		StreamFunction: func(_ json.RawMessage, output io.Writer) (string, error) {
+			io.WriteString(output, "progress line\n")
+			return "final result", nil
+		},
+	}
+	agent := newTestAgent(nil, []ToolDefinition{streamed})
+
+	var result ToolResult
+	output := captureStdout(t, func() {
+		var err error
+		result, err = agent.callTool(streamed, nil)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	if !strings.Contains(output, "progress line") {
+		t.Errorf("stream was not shown to the user: %q", output)
+	}
+	if content, isError := result.content(); content != "final result" || isError {
+		t.Errorf("Claude is sent %q, want only the final result", content)
+	}
+}
+
+func TestQuietSuppressesToolLine(t *testing.T) {
+	setupWorkspace(t)
+	config.Quiet = true
+	writeFile(t, "greeting.txt", "hello")
+
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "It says hello", nil))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition}, "Read greeting.txt")
+
+	output := captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if strings.Contains(output, "tool:") || strings.Contains(output, "read_file") {
+		t.Errorf("quiet output has a tool line:\n%s", output)
+	}
+	if !strings.Contains(output, "Claude: It says hello") {
+		t.Errorf("quiet output is missing Claude's response:\n%s", output)
+	}
+}
+
+func TestToolTimeout(t *testing.T) {
+	setupWorkspace(t)
+
+	slow := ToolDefinition{
+		Name:    "slow",
+		Timeout: 20 * time.Millisecond,
+		Function: func(json.RawMessage) (string, error) {
+			time.Sleep(time.Second)
+			return "finished", nil
+		},
+	}
+	agent := newTestAgent(nil, []ToolDefinition{slow})
+
+	start := time.Now()
+	_, err := agent.callToolWithTimeout(slow, json.RawMessage(`{}`))
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("got error %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("gave up after %s, want about the 20ms timeout", elapsed)
+	}
+
+	captureStdout(t, func() {
+		content, isError := agent.runTool("tool_1", "slow", json.RawMessage(`{}`)).content()
+		if !isError || !strings.Contains(content, "did not finish within 20ms") {
+			t.Errorf("got result %q (error %v), want a timeout error result", content, isError)
+		}
+	})
+}
+
+func TestToolTimeoutLetsMutatingToolsFinish(t *testing.T) {
+	setupWorkspace(t)
+
+	writer := ToolDefinition{
+		Name:    "writer",
+		Timeout: 20 * time.Millisecond,
+		Mutates: true,
+		Function: func(json.RawMessage) (string, error) {
+			time.Sleep(100 * time.Millisecond)
+			return "written", os.WriteFile("out.txt", []byte("done"), 0644)
+		},
+	}
+	agent := newTestAgent(nil, []ToolDefinition{writer})
+
+	result, err := agent.callToolWithTimeout(writer, json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := result.content(); content != "written" {
+		t.Errorf("got %q, want the completed result", content)
+	}
+	if got := readFile(t, "out.txt"); got != "done" {
+		t.Errorf("file has %q", got)
+	}
+}
+
+func TestToolPromptJSONPretty(t *testing.T) {
+	input := json.RawMessage(`{"path":"a.go","edits":[{"line":1,"text":"x"}]}`)
+
+	tests := []struct {
+		pretty bool
+		want   string
+	}{
+		{false, "tool: edit(" + string(input) + ")\n"},
+		{true, "tool: edit({\n  \"path\": \"a.go\",\n  \"edits\": [\n    {\n      \"line\": 1,\n      \"text\": \"x\"\n    }\n  ]\n})\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.pretty), func(t *testing.T) {
+			setupWorkspace(t)
+			config.JSONPretty = test.pretty
+			agent := newTestAgent(nil, nil)
+
+			output := captureStdout(t, func() {
+				agent.toolPrompt("tool_1", "edit", input)
+			})
+
+			if output != test.want {
+				t.Errorf("got %q, want %q", output, test.want)
+			}
+		})
+	}
+}
+
+func TestToolResultInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"valid", "héllo", "héllo"},
+		{"invalid bytes", "bad \xff\xfe bytes", "bad \uFFFD bytes\n\n(invalid UTF-8 in this result was replaced with U+FFFD)"},
+		{"truncated rune", "cut \xe2\x82", "cut \uFFFD\n\n(invalid UTF-8 in this result was replaced with U+FFFD)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			config.Quiet = true
+
+			echo := ToolDefinition{
+				Name:     "echo",
+				Function: func(json.RawMessage) (string, error) { return test.output, nil },
+			}
+			agent := newTestAgent(nil, []ToolDefinition{echo})
+
+			block := agent.executeTool("tool_1", "echo", json.RawMessage(`{}`))
+			got := block.OfToolResult.Content[0].OfText.Text
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if _, err := json.Marshal(block); err != nil {
+				t.Errorf("result block doesn't encode: %v", err)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{1234 * time.Microsecond, "1ms"},
+		{250 * time.Millisecond, "250ms"},
+		{3240 * time.Millisecond, "3.2s"},
+		{90 * time.Second, "1m30s"},
+	}
+
+	for _, test := range tests {
+		if got := formatDuration(test.d); got != test.want {
+			t.Errorf("formatDuration(%s) = %q, want %q", test.d, got, test.want)
+		}
+	}
+}
+
+func TestToolDurationRecorded(t *testing.T) {
+	setupWorkspace(t)
+
+	sleeper := ToolDefinition{
+		Name: "sleeper",
+		Function: func(json.RawMessage) (string, error) {
+			time.Sleep(50 * time.Millisecond)
+			return "awake", nil
+		},
+	}
+	agent := newTestAgent(nil, []ToolDefinition{sleeper})
+
+	output := captureStdout(t, func() {
+		agent.executeTool("tool_1", "sleeper", json.RawMessage(`{}`))
+	})
+
+	match := regexp.MustCompile(`tool: sleeper finished \[(\d+)ms\]`).FindStringSubmatch(output)
+	if match == nil {
+		t.Fatalf("output %q has no duration", output)
+	}
+	if ms, _ := strconv.Atoi(match[1]); ms < 50 {
+		t.Errorf("recorded %sms for a tool that slept 50ms", match[1])
+	}
+	if agent.latency.tools < 50*time.Millisecond {
+		t.Errorf("tool latency %s, want at least 50ms", agent.latency.tools)
+	}
+}
+
+func TestReviewResultsAttachesNote(t *testing.T) {
+	setupWorkspace(t)
+	config.ReviewResults = true
+	writeFile(t, "greeting.txt", "hello")
+
+	var toolResult string
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "Noted", &toolResult))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition}, "Read greeting.txt", "this file is out of date")
+
+	captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if !strings.Contains(toolResult, "hello") || !strings.Contains(toolResult, "Note from the user about this result: this file is out of date") {
+		t.Errorf("tool result sent to Claude = %s, want the content with the note", toolResult)
+	}
+	if result := agent.conversation[2].Content[0].OfToolResult; result == nil || !strings.Contains(toolResultText(result), "this file is out of date") {
+		t.Error("the note is not in the conversation")
+	}
+}
+
+// requestToolNames returns the names of the tools offered to Claude in a request
+func requestToolNames(request map[string]any) []string {
+	names := []string{}
+	tools, _ := request["tools"].([]any)
+	for _, tool := range tools {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	return names
+}
+
+// modelAPI serves the Messages API, answering with status for the models listed in failing and with a reply from
+// the requested model otherwise, recording the model of each request
+func modelAPI(t *testing.T, failing map[string]int, requested *[]string) *anthropic.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Model string `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*requested = append(*requested, request.Model)
+
+		w.Header().Set("Content-Type", "application/json")
+		if status, ok := failing[request.Model]; ok {
+			w.WriteHeader(status)
+			io.WriteString(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+			return
+		}
+		io.WriteString(w, strings.Replace(messageJSON(textBlock("served")), `"test-model"`, strconv.Quote(request.Model), 1))
+	}))
+	t.Cleanup(server.Close)
+
+	client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	return &client
+}
+
+func TestModelFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		failing   map[string]int
+		requested []string
+		served    string
+	}{
+		{name: "primary available", failing: map[string]int{}, requested: []string{"primary"}, served: "primary"},
+		{name: "primary overloaded", failing: map[string]int{"primary": 529}, requested: []string{"primary", "first"}, served: "first"},
+		{name: "two overloaded", failing: map[string]int{"primary": 529, "first": 529}, requested: []string{"primary", "first", "second"}, served: "second"},
+		{name: "other errors don't fall back", failing: map[string]int{"primary": 400}, requested: []string{"primary"}},
+		{name: "all overloaded", failing: map[string]int{"primary": 529, "first": 529, "second": 529}, requested: []string{"primary", "first", "second"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			config.Model = "primary"
+			config.ModelFallbacks = []string{"first", "primary", "second"}
+			config.Output = OutputJSON
+
+			var requested []string
+			agent := newTestAgent(modelAPI(t, test.failing, &requested), nil)
+			var err error
+			output := captureStdout(t, func() {
+				err = agent.RunOnce(context.Background(), "hi")
+			})
+
+			if !slices.Equal(requested, test.requested) {
+				t.Errorf("requested %v, want %v", requested, test.requested)
+			}
+			if test.served == "" {
+				if err == nil {
+					t.Error("got no error when no model could serve the request")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprintf(`{"type":"usage","model":%q`, test.served); !strings.Contains(output, want) {
+				t.Errorf("output %s doesn't record %s serving the turn", output, test.served)
+			}
+		})
+	}
+}
+
+func TestBetaContext1MHeader(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+			setupWorkspace(t)
+			config.BetaContext1M = enabled
+
+			var beta []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				beta = r.Header.Values("anthropic-beta")
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, messageJSON(textBlock("ok")))
+			}))
+			t.Cleanup(server.Close)
+			client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+
+			agent := newTestAgent(&client, nil)
+			if _, err := agent.runInference(context.Background(), []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock("hi"))}); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := slices.Contains(beta, "context-1m-2025-08-07"); got != enabled {
+				t.Errorf("anthropic-beta headers %v, want the 1M context beta %v", beta, enabled)
+			}
+		})
+	}
+}
+
+func TestDisabledToolNotSent(t *testing.T) {
+	setupWorkspace(t)
+
+	tools, err := filterTools([]ToolDefinition{ReadFileDefinition, ListFilesDefinition, EditFileDefinition}, nil, []string{"edit_file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []string
+	client := fakeAPI(t, func(request map[string]any) string {
+		sent = requestToolNames(request)
+		return messageJSON(textBlock("ok"))
+	})
+	agent := newTestAgent(client, tools)
+	if _, err := agent.runInference(context.Background(), []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock("hi"))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"read_file", "list_files"}; !slices.Equal(sent, want) {
+		t.Errorf("tools sent %v, want %v", sent, want)
+	}
+}
+
+func TestNoToolsSendsNone(t *testing.T) {
+	setupWorkspace(t)
+
+	requests := 0
+	client := fakeAPI(t, func(request map[string]any) string {
+		requests++
+		if _, ok := request["tools"]; ok {
+			t.Errorf("request has tools %v", request["tools"])
+		}
+		return messageJSON(textBlock("Just chatting"))
+	})
+	agent := newTestAgent(client, nil)
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "hello"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if requests != 1 {
+		t.Errorf("sent %d requests, want a single reply with no tool round trip", requests)
+	}
+	if !strings.Contains(output, "Just chatting") || strings.Contains(output, "tool:") {
+		t.Errorf("output %q, want only the reply", output)
+	}
+}
+
+func TestStopSequencesSent(t *testing.T) {
+	setupWorkspace(t)
+	config.StopSequences = []string{"END", "###"}
+
+	var sent any
+	client := fakeAPI(t, func(request map[string]any) string {
+		sent = request["stop_sequences"]
+		return messageJSON(textBlock("ok"))
+	})
+	agent := newTestAgent(client, nil)
+	if _, err := agent.runInference(context.Background(), []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock("hi"))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fmt.Sprint(sent); got != "[END ###]" {
+		t.Errorf("stop sequences sent %s, want [END ###]", got)
+	}
+}
+
+func TestFilterTools(t *testing.T) {
+	tools := []ToolDefinition{ReadFileDefinition, ListFilesDefinition, EditFileDefinition}
+	names := func(tools []ToolDefinition) []string {
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	enabled, err := filterTools(tools, []string{"read_file", "edit_file"}, []string{"edit_file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(enabled); !slices.Equal(got, []string{"read_file"}) {
+		t.Errorf("got %v, want only read_file", got)
+	}
+
+	if _, err := filterTools(tools, nil, []string{"edit_flie"}); err == nil {
+		t.Error("an unknown tool name was accepted")
+	}
+}
+
+func TestEOFRunsShutdown(t *testing.T) {
+	setupWorkspace(t)
+	config.Transcript = "transcript.md"
+	config.Session = "session.json"
+
+	client := fakeAPI(t, func(map[string]any) string { return messageJSON(textBlock("Hi there")) })
+	agent := newTestAgent(client, nil, "hello")
+
+	output := captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if !strings.Contains(readFile(t, "transcript.md"), "Hi there") {
+		t.Error("transcript was not written at the end of input")
+	}
+	if !strings.Contains(readFile(t, "session.json"), "Hi there") {
+		t.Error("session was not saved at the end of input")
+	}
+	if !strings.Contains(output, "Session ended after 1 turns: 10 input tokens, 5 output tokens") {
+		t.Errorf("summary missing from output:\n%s", output)
+	}
+}
+
+func TestEchoInput(t *testing.T) {
+	for _, echo := range []bool{true, false} {
+		t.Run(fmt.Sprint(echo), func(t *testing.T) {
+			setupWorkspace(t)
+			config.EchoInput = echo
+
+			client := fakeAPI(t, func(map[string]any) string { return messageJSON(textBlock("Hi there")) })
+			agent := newTestAgent(client, nil, "hello from a pipe")
+
+			output := captureStdout(t, func() {
+				if err := agent.Run(context.Background()); err != nil {
+					t.Error(err)
+				}
+			})
+
+			if got := strings.Contains(output, "You: hello from a pipe\n"); got != echo {
+				t.Errorf("output %q, echoed %v", output, got)
+			}
+		})
+	}
+}
+
+// failingOnceAPI serves the Messages API, failing the first request with a non-retryable error and answering the
+// rest with text, recording the text of the last message in each request
+func failingOnceAPI(t *testing.T, lastUserText *[]string) *anthropic.Client {
+	t.Helper()
+
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Messages []struct {
+				Content []struct {
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		last := request.Messages[len(request.Messages)-1]
+		*lastUserText = append(*lastUserText, last.Content[0].Text)
+
+		w.Header().Set("Content-Type", "application/json")
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"type":"error","error":{"type":"invalid_request_error","message":"something went wrong"}}`)
+			return
+		}
+		io.WriteString(w, messageJSON(textBlock("Hi there")))
+	}))
+	t.Cleanup(server.Close)
+
+	client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	return &client
+}
+
+func TestFailedTurnOffersRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		sent    []string
+		wantErr bool
+	}{
+		{name: "retry", lines: []string{"hello", "r", "thanks"}, sent: []string{"hello", "hello", "thanks"}},
+		{name: "edit", lines: []string{"hello", "e", "hello again"}, sent: []string{"hello", "hello again"}},
+		{name: "quit", lines: []string{"hello", "q"}, sent: []string{"hello"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+
+			var sent []string
+			agent := newTestAgent(failingOnceAPI(t, &sent), nil, test.lines...)
+
+			var err error
+			output := captureStdout(t, func() {
+				err = agent.Run(context.Background())
+			})
+
+			if (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error %v", err, test.wantErr)
+			}
+			if !strings.Contains(output, "something went wrong") || !strings.Contains(output, "[r]etry") {
+				t.Errorf("output %q doesn't offer a retry after the error", output)
+			}
+			if !slices.Equal(sent, test.sent) {
+				t.Errorf("sent %q, want %q", sent, test.sent)
+			}
+			if !test.wantErr && !strings.Contains(output, "Hi there") {
+				t.Errorf("output %q, want the session to continue", output)
+			}
+		})
+	}
+}
+
+func TestFirstUserMessageSeedsConversation(t *testing.T) {
+	setupWorkspace(t)
+	config.FirstUserMessage = "review the open TODOs"
+
+	var firstRequest []any
+	client := fakeAPI(t, func(request map[string]any) string {
+		if firstRequest == nil {
+			firstRequest = request["messages"].([]any)
+		}
+		return messageJSON(textBlock("Done"))
+	})
+	agent := newTestAgent(client, nil, "thanks")
+
+	captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if len(firstRequest) != 1 {
+		t.Fatalf("first request sent %d messages, want only the seeded one", len(firstRequest))
+	}
+	if got := agent.conversation[0].Content[0].OfText.Text; got != config.FirstUserMessage {
+		t.Errorf("first conversation entry is %q, want the seeded message", got)
+	}
+	if got := agent.conversation[2].Content[0].OfText.Text; got != "thanks" {
+		t.Errorf("third conversation entry is %q, want the typed thanks after the seeded turn", got)
+	}
+}
+
+func TestSessionTimeout(t *testing.T) {
+	setupWorkspace(t)
+	config.Timeout = 100 * time.Millisecond
+	config.Session = "session.json"
+	config.FirstUserMessage = "hello"
+
+	// The reply outlasts the deadline, and then input never arrives
+	client := fakeAPI(t, func(map[string]any) string {
+		time.Sleep(200 * time.Millisecond)
+		return messageJSON(textBlock("Hi there"))
+	})
+	blocked := make(chan struct{})
+	t.Cleanup(func() { close(blocked) })
+	agent := NewAgent(client, func() (string, bool) {
+		<-blocked
+		return "", false
+	}, nil)
+
+	start := time.Now()
+	output := captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run took %s to stop", elapsed)
+	}
+	if !strings.Contains(output, "Hi there") {
+		t.Errorf("output %q, want the turn in progress to finish", output)
+	}
+	if !strings.Contains(output, "session timeout of 100ms reached") {
+		t.Errorf("output %q doesn't report the timeout", output)
+	}
+	if !strings.Contains(readFile(t, "session.json"), "Hi there") {
+		t.Error("session was not saved when the timeout ended it")
+	}
+}
+
+func TestNoHistoryWritesNothing(t *testing.T) {
+	setupWorkspace(t)
+	var err error
+	config, err = ParseFlags([]string{"--no-history", "--transcript", "transcript.md", "--session", "session.json", "--export", "openai=export.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := fakeAPI(t, func(map[string]any) string { return messageJSON(textBlock("Hi there")) })
+	agent := newTestAgent(client, nil, "hello")
+	captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("%s was written although --no-history is set", entry.Name())
+	}
+}
+
+func TestMaxToolTurnsStopsLoop(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxToolTurns = 3
+	writeFile(t, "greeting.txt", "hello")
+
+	requests := 0
+	client := fakeAPI(t, func(map[string]any) string {
+		requests++
+		return messageJSON(toolUseBlock(fmt.Sprintf("tool_%d", requests), "read_file", `{"path":"greeting.txt"}`))
+	})
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition}, "keep reading")
+
+	output := captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if requests != 4 {
+		t.Errorf("sent %d requests, want the first plus 3 tool turns", requests)
+	}
+	if !strings.Contains(output, "stopped after 3 consecutive tool calls") {
+		t.Errorf("output doesn't explain the stop:\n%s", output)
+	}
+}
+
+func TestMaxToolTurnsInRunOnce(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxToolTurns = 1
+	writeFile(t, "greeting.txt", "hello")
+
+	client := fakeAPI(t, func(map[string]any) string {
+		return messageJSON(toolUseBlock("tool_1", "read_file", `{"path":"greeting.txt"}`))
+	})
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+
+	captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "keep reading"); !errors.Is(err, ErrToolTurnLimit) {
+			t.Errorf("got error %v, want ErrToolTurnLimit", err)
+		}
+	})
+}
+
+func TestMaxToolCallsPerMessage(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxToolCalls = 2
+	writeFile(t, "greeting.txt", "hello")
+
+	var results []map[string]any
+	client := fakeAPI(t, func(request map[string]any) string {
+		messages := request["messages"].([]any)
+		if len(messages) == 1 {
+			call := `{"path":"greeting.txt"}`
+			return messageJSON(toolUseBlock("tool_1", "read_file", call), toolUseBlock("tool_2", "read_file", call), toolUseBlock("tool_3", "read_file", call))
+		}
+		for _, block := range messages[len(messages)-1].(map[string]any)["content"].([]any) {
+			results = append(results, block.(map[string]any))
+		}
+		return messageJSON(textBlock("done"))
+	})
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "read it three times"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if strings.Count(output, "read_file(") != 2 {
+		t.Errorf("want 2 tool calls run:\n%s", output)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d tool results, want one for every call", len(results))
+	}
+	for i, result := range results[:2] {
+		if result["is_error"] == true {
+			t.Errorf("result %d is an error: %v", i+1, result)
+		}
+	}
+	last, _ := json.Marshal(results[2])
+	if results[2]["tool_use_id"] != "tool_3" || results[2]["is_error"] != true || !strings.Contains(string(last), "only 2 tool calls are run per message") {
+		t.Errorf("third result = %s, want the limit reached", last)
+	}
+}
+
+func TestEditFileExpectedHash(t *testing.T) {
+	const helloHash = "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+
+	tests := []struct {
+		name    string
+		hash    string
+		want    string
+		wantErr error
+	}{
+		{name: "matching", hash: helloHash, want: "goodbye world"},
+		{name: "matching upper case", hash: strings.ToUpper(helloHash), want: "goodbye world"},
+		{name: "mismatched", hash: strings.Repeat("0", 64), want: "hello world", wantErr: ErrConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "file.txt", "hello world")
+
+			_, err := callTool(t, EditFile, map[string]any{"path": "file.txt", "old_str": "hello", "new_str": "goodbye", "expected_sha256": test.hash})
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+			if got := readFile(t, "file.txt"); got != test.want {
+				t.Errorf("file has %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEditFileRegexp(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "main.go", "func main() {\n\tx  :=   1\n}\n")
+
+	_, err := callTool(t, EditFile, map[string]any{"path": "main.go", "old_regexp": `x\s*:=\s*1`, "new_str": "x := $2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// new_str is taken literally rather than expanding submatches
+	if got := readFile(t, "main.go"); got != "func main() {\n\tx := $2\n}\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestEditFileRegexpRejections(t *testing.T) {
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  error
+	}{
+		{name: "multiple matches", input: map[string]any{"path": "file.txt", "old_regexp": `a+`, "new_str": "b"}, want: ErrMultipleMatches},
+		{name: "no match", input: map[string]any{"path": "file.txt", "old_regexp": `z+`, "new_str": "b"}, want: ErrNotFound},
+		{name: "invalid", input: map[string]any{"path": "file.txt", "old_regexp": `(`, "new_str": "b"}, want: ErrInvalidInput},
+		{name: "with old_str", input: map[string]any{"path": "file.txt", "old_str": "a", "old_regexp": `a`, "new_str": "b"}, want: ErrInvalidInput},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "file.txt", "aa x aaa\n")
+
+			_, err := callTool(t, EditFile, test.input)
+			if !errors.Is(err, test.want) {
+				t.Errorf("got error %v, want %v", err, test.want)
+			}
+			if got := readFile(t, "file.txt"); got != "aa x aaa\n" {
+				t.Errorf("file changed to %q", got)
+			}
+		})
+	}
+}
+
+func TestEditFileAlreadyApplied(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", "hello world\n")
+	edit := map[string]any{"path": "file.txt", "old_str": "hello", "new_str": "goodbye"}
+
+	if _, err := callTool(t, EditFile, edit); err != nil {
+		t.Fatal(err)
+	}
+	got, err := callTool(t, EditFile, edit)
+	if err != nil {
+		t.Fatalf("re-applying the edit failed: %v", err)
+	}
+	if got != "no change needed; new_str already present" {
+		t.Errorf("got %q", got)
+	}
+	if content := readFile(t, "file.txt"); content != "goodbye world\n" {
+		t.Errorf("file has %q", content)
+	}
+}
+
+func TestThinkingShownAndPreserved(t *testing.T) {
+	setupWorkspace(t)
+	config.Thinking = 2048
+	writeFile(t, "greeting.txt", "hello")
+
+	var requests []map[string]any
+	thinking := `{"type":"thinking","thinking":"I should read the file first","signature":"sig"}`
+	client := fakeAPI(t, func(request map[string]any) string {
+		requests = append(requests, request)
+		if len(requests) == 1 {
+			return messageJSON(thinking, toolUseBlock("tool_1", "read_file", `{"path":"greeting.txt"}`))
+		}
+		return messageJSON(textBlock("It says hello"))
+	})
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+	agent.colors = true
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "Read greeting.txt"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if want := agent.colorize(ANSI_DIM, "Claude is thinking: I should read the file first"); !strings.Contains(output, want) {
+		t.Errorf("thinking not shown dimmed:\n%s", output)
+	}
+
+	budget, _ := requests[0]["thinking"].(map[string]any)
+	if budget["type"] != "enabled" || budget["budget_tokens"] != 2048.0 {
+		t.Errorf("first request thinking = %v", requests[0]["thinking"])
+	}
+
+	// The tool result follow-up must carry the thinking block back with its signature
+	messages := requests[1]["messages"].([]any)
+	assistant := messages[1].(map[string]any)["content"].([]any)
+	block := assistant[0].(map[string]any)
+	if block["type"] != "thinking" || block["thinking"] != "I should read the file first" || block["signature"] != "sig" {
+		t.Errorf("thinking block not preserved in history: %v", assistant)
+	}
+}
+
+func TestThinkingBudgetForwarded(t *testing.T) {
+	tests := []struct {
+		name      string
+		thinking  int64
+		maxTokens int64
+		want      float64
+	}{
+		{"no thinking", 0, 0, defaultMaxTokens},
+		{"budget on top of the default", 2048, 0, defaultMaxTokens + 2048},
+		{"explicit max tokens", 2048, 3000, 3000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			config.Thinking = test.thinking
+			config.MaxTokens = test.maxTokens
+
+			var request map[string]any
+			client := fakeAPI(t, func(r map[string]any) string {
+				request = r
+				return messageJSON(textBlock("Hi"))
+			})
+			captureStdout(t, func() {
+				if err := newTestAgent(client, nil).RunOnce(context.Background(), "hello"); err != nil {
+					t.Error(err)
+				}
+			})
+
+			if request["max_tokens"] != test.want {
+				t.Errorf("max_tokens is %v, want %v", request["max_tokens"], test.want)
+			}
+			budget, _ := request["thinking"].(map[string]any)
+			if test.thinking == 0 && request["thinking"] != nil {
+				t.Errorf("thinking %v sent without a budget", request["thinking"])
+			}
+			if test.thinking != 0 && budget["budget_tokens"] != float64(test.thinking) {
+				t.Errorf("thinking is %v, want a budget of %d", request["thinking"], test.thinking)
+			}
+		})
+	}
+}
+
+func TestThinkingTokensTallied(t *testing.T) {
+	setupWorkspace(t)
+	config.Thinking = 2048
+	writeFile(t, "greeting.txt", "hello")
+
+	thoughts := []string{"I should read the file first", "The file says hello, so I can answer"}
+	requests := 0
+	client := fakeAPI(t, func(map[string]any) string {
+		requests++
+		thinking := fmt.Sprintf(`{"type":"thinking","thinking":%q,"signature":"sig"}`, thoughts[requests-1])
+		if requests == 1 {
+			return messageJSON(thinking, toolUseBlock("tool_1", "read_file", `{"path":"greeting.txt"}`))
+		}
+		return messageJSON(thinking, textBlock("It says hello"))
+	})
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "Read greeting.txt"); err != nil {
+			t.Error(err)
+		}
+		agent.summaryPrompt()
+	})
+
+	want := int64(estimateTokens(thoughts[0]) + estimateTokens(thoughts[1]))
+	if agent.usage.ThinkingTokens != want || agent.usage.OutputTokens != 10 {
+		t.Errorf("tallied %d thinking of %d output tokens, want %d of 10", agent.usage.ThinkingTokens, agent.usage.OutputTokens, want)
+	}
+	if summary := fmt.Sprintf("10 output tokens (about %d of them thinking)", want); !strings.Contains(output, summary) {
+		t.Errorf("summary %q lacks %q", output, summary)
+	}
+	if transcript := renderTranscript(agent.conversation, agent.usage); !strings.Contains(transcript, fmt.Sprintf("- Thinking tokens (estimated, part of output): %d\n", want)) {
+		t.Errorf("transcript lacks the thinking tokens:\n%s", transcript)
+	}
+}
+
+func TestListFilesHidden(t *testing.T) {
+	tests := []struct {
+		name  string
+		flag  bool
+		input map[string]any
+		want  string
+	}{
+		{name: "default", input: map[string]any{}, want: `["main.go"]`},
+		{name: "include_hidden input", input: map[string]any{"include_hidden": true}, want: `[".env",".git/",".git/HEAD","main.go"]`},
+		{name: "--include-hidden", flag: true, input: map[string]any{}, want: `[".env",".git/",".git/HEAD","main.go"]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			config.IncludeHidden = test.flag
+			writeFile(t, "main.go", "package main\n")
+			writeFile(t, ".env", "SECRET=1\n")
+			writeFile(t, ".git/HEAD", "ref: refs/heads/main\n")
+
+			got, err := callTool(t, ListFiles, test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestListFilesWithMetadata(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "main.go", "package main\n")
+	writeFile(t, "pkg/util.go", "package pkg\n\nfunc Util() {}\n")
+	modified := time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC)
+	if err := os.Chtimes("main.go", modified, modified.Add(500*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	flat, err := callTool(t, ListFiles, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["main.go","pkg/","pkg/util.go"]`; flat != want {
+		t.Errorf("default output %s, want %s", flat, want)
+	}
+
+	got, err := callTool(t, ListFiles, map[string]any{"with_metadata": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []fileEntry
+	if err := json.Unmarshal([]byte(got), &entries); err != nil {
+		t.Fatalf("metadata output %s: %v", got, err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %s", len(entries), got)
+	}
+	if want := (fileEntry{Path: "main.go", Size: 13, ModifiedTime: modified}); entries[0] != want {
+		t.Errorf("main.go entry is %+v, want %+v", entries[0], want)
+	}
+	if entries[2].Path != "pkg/util.go" || entries[2].Size != 28 {
+		t.Errorf("pkg/util.go entry is %+v", entries[2])
+	}
+	if !strings.Contains(got, `"modified_time":"2024-05-01T12:30:45Z"`) {
+		t.Errorf("output %s lacks the RFC 3339 modification time", got)
+	}
+}
+
+func TestWorkingDir(t *testing.T) {
+	dir := setupWorkspace(t)
+	writeFile(t, "project/file.txt", "inside")
+	writeFile(t, "file.txt", "outside")
+
+	cfg, err := ParseFlags([]string{"-C", "project", "--transcript", "transcript.md", "--replay", "session.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enterWorkingDir(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := callTool(t, ReadFile, map[string]any{"path": "file.txt"})
+	if err != nil || got != "inside" {
+		t.Errorf("read %q, %v, want the file in the working directory", got, err)
+	}
+	if _, err := callTool(t, ReadFile, map[string]any{"path": "../file.txt"}); !errors.Is(err, ErrOutsideWorkspace) {
+		t.Errorf("got error %v, want ErrOutsideWorkspace for the starting directory", err)
+	}
+	if want := filepath.Join(dir, "transcript.md"); cfg.Transcript != want {
+		t.Errorf("transcript path is %s, want %s", cfg.Transcript, want)
+	}
+	if want := filepath.Join(dir, "session.json"); cfg.Replay != want {
+		t.Errorf("replay path is %s, want %s", cfg.Replay, want)
+	}
+}
+
+func TestWorkingDirTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"file", "prompts/review.tmpl", "the template beside the agent"},
+		{"name", "review", "the workspace template"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "prompts/review.tmpl", "the template beside the agent")
+			writeFile(t, filepath.Join("project", templateDir, "review.tmpl"), "the workspace template")
+
+			cfg, err := ParseFlags([]string{"-C", "project", "--template", test.template})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := enterWorkingDir(&cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := renderTemplate(cfg.Template, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("rendered %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWorkingDirMustExist(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", "not a directory")
+
+	for _, dir := range []string{"missing", "file.txt"} {
+		if _, err := ParseFlags([]string{"--working-dir", dir}); err == nil {
+			t.Errorf("--working-dir %s was accepted", dir)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// outputEvent is a single line of --output json, describing one thing that happened during a turn
+type outputEvent struct {
+	Type           string          `json:"type"`
+	ID             string          `json:"id,omitempty"`
+	Name           string          `json:"name,omitempty"`
+	Model          string          `json:"model,omitempty"`
+	Text           string          `json:"text,omitempty"`
+	Input          json.RawMessage `json:"input,omitempty"`
+	IsError        bool            `json:"is_error,omitempty"`
+	InputTokens    int64           `json:"input_tokens,omitempty"`
+	OutputTokens   int64           `json:"output_tokens,omitempty"`
+	ThinkingTokens int64           `json:"thinking_tokens,omitempty"`
+	DurationMS     int64           `json:"duration_ms,omitempty"`
+}
+
+// emit writes the event to stdout as a line of JSON when JSON output is enabled
+func (a *Agent) emit(event outputEvent) {
+	if !a.jsonOutput {
+		return
+	}
+
+	// Tool traffic can carry file contents and credentials, so mask it before it reaches any log
+	if event.Type == "tool_result" || event.Type == "question" {
+		event.Text = redactSecrets(event.Text)
+	}
+	if event.Input != nil {
+		event.Input = redactJSON(event.Input)
+	}
+
+	json.NewEncoder(os.Stdout).Encode(event)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJSONOutputEvents(t *testing.T) {
+	setupWorkspace(t)
+	config.Output = OutputJSON
+	writeFile(t, "greeting.txt", "hello")
+
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "It says hello", nil))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "Read greeting.txt"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	var events []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var event map[string]any
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		events = append(events, event)
+	}
+
+	want := []struct {
+		eventType string
+		fields    map[string]any
+	}{
+		{"user_message", map[string]any{"text": "Read greeting.txt"}},
+		{"usage", map[string]any{"model": "test-model", "input_tokens": 10.0, "output_tokens": 5.0}},
+		{"tool_call", map[string]any{"id": "tool_1", "name": "read_file"}},
+		{"tool_result", map[string]any{"id": "tool_1", "name": "read_file", "text": "hello"}},
+		{"usage", map[string]any{"model": "test-model"}},
+		{"assistant_text", map[string]any{"text": "It says hello"}},
+		{"session_end", map[string]any{"input_tokens": 20.0, "output_tokens": 10.0}},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d:\n%s", len(events), len(want), output)
+	}
+	for i, event := range events {
+		if event["type"] != want[i].eventType {
+			t.Errorf("event %d has type %v, want %s", i, event["type"], want[i].eventType)
+		}
+		for field, value := range want[i].fields {
+			if event[field] != value {
+				t.Errorf("%s event has %s %v, want %v", want[i].eventType, field, event[field], value)
+			}
+		}
+	}
+
+	input, _ := json.Marshal(events[2]["input"])
+	if string(input) != `{"path":"greeting.txt"}` {
+		t.Errorf("tool_call input = %s", input)
+	}
+}
+
+// decodeEvents decodes every line of --output json, failing the test on any that isn't a JSON event
+func decodeEvents(t *testing.T, output string) []outputEvent {
+	t.Helper()
+
+	var events []outputEvent
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		var event outputEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("line %q is not JSON: %v\n%s", line, err, output)
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// questions returns the text of the question events
+func questions(events []outputEvent) []string {
+	var texts []string
+	for _, event := range events {
+		if event.Type == "question" {
+			texts = append(texts, event.Text)
+		}
+	}
+
+	return texts
+}
+
+func TestJSONOutputRetryQuestion(t *testing.T) {
+	setupWorkspace(t)
+	config.Output = OutputJSON
+
+	var requests []string
+	agent := newTestAgent(failingOnceAPI(t, &requests), nil, "hello", "r")
+	output := captureStdout(t, func() {
+		if err := agent.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	got := questions(decodeEvents(t, output))
+	if want := []string{"[r]etry, [e]dit your last message or [q]uit"}; !slices.Equal(got, want) {
+		t.Errorf("got questions %q, want %q", got, want)
+	}
+	if len(requests) != 2 {
+		t.Errorf("sent %d requests, want the failed one retried", len(requests))
+	}
+}
+
+func TestJSONOutputApprovalQuestions(t *testing.T) {
+	setupGitRepo(t, map[string]string{"a.txt": "a\n", "b.txt": "b\n"})
+	writeFile(t, "a.txt", "a\nedited\n")
+	config.Output = OutputJSON
+	config.BatchApprove = true
+	config.GitCheck = true
+	config.ReviewResults = true
+	config.ToolPolicies = map[string]string{"edit_file": PolicyAsk, "read_file": PolicyAsk}
+
+	client := fakeAPI(t, func(request map[string]any) string {
+		switch len(request["messages"].([]any)) {
+		case 1:
+			return messageJSON(
+				toolUseBlock("tool_1", "edit_file", `{"path":"a.txt","old_str":"a\n","new_str":"A\n"}`),
+				toolUseBlock("tool_2", "edit_file", `{"path":"b.txt","old_str":"b","new_str":"B"}`),
+			)
+		case 3:
+			return messageJSON(toolUseBlock("tool_3", "read_file", `{"path":"b.txt"}`))
+		}
+		return messageJSON(textBlock("Done"))
+	})
+	agent := newTestAgent(client, []ToolDefinition{EditFileDefinition, ReadFileDefinition}, "a", "y", "", "", "y", "")
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "edit both"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	want := []string{
+		"Claude wants to make these changes:\n  1. edit_file a.txt\n  2. edit_file b.txt\nAllow [a]ll, [n]one, or the numbers to allow, e.g. 1 3",
+		"a.txt has uncommitted changes, change it anyway? [y/N]",
+		"OK\nNote on edit_file result (enter to skip)",
+		"OK\nNote on edit_file result (enter to skip)",
+		"Allow read_file? [y/N]",
+		"B\n\nNote on read_file result (enter to skip)",
+	}
+	if got := questions(decodeEvents(t, output)); !slices.Equal(got, want) {
+		t.Errorf("got questions %q, want %q", got, want)
+	}
+	if got := readFile(t, "b.txt"); got != "B\n" {
+		t.Errorf("b.txt has %q", got)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var PackageAPIDefinition = ToolDefinition{
+	Name:        "package_api",
+	Description: "List the exported API of a Go package: its constants, variables, functions, types and methods, without reading its source files. Set 'docs' to include the documentation of each declaration. Use this when integrating a dependency.",
+	InputSchema: PackageAPIInputSchema,
+	Function:    PackageAPI,
+	Timeout:     commandToolTimeout,
+}
+
+type PackageAPIInput struct {
+	ImportPath string `json:"import_path" jsonschema_description:"The import path of the package, e.g. 'net/http' or 'github.com/anthropics/anthropic-sdk-go'."`
+	Docs       bool   `json:"docs,omitempty" jsonschema_description:"Include the doc comments of each declaration."`
+}
+
+var PackageAPIInputSchema = GenerateSchema[PackageAPIInput]()
+
+func PackageAPI(input json.RawMessage) (string, error) {
+	packageAPIInput := PackageAPIInput{}
+	err := json.Unmarshal(input, &packageAPIInput)
+	if err != nil {
+		return "", err
+	}
+
+	importPath := strings.TrimSpace(packageAPIInput.ImportPath)
+	if importPath == "" || strings.HasPrefix(importPath, "-") || strings.Contains(importPath, ".") && !strings.Contains(importPath, "/") {
+		return "", fmt.Errorf("import_path must be a package import path: %w", ErrInvalidInput)
+	}
+
+	output, err := runCommand("go", "doc", "-all", importPath)
+	if err != nil {
+		if output != "" {
+			return "", fmt.Errorf("go doc %s failed: %s", importPath, strings.TrimSpace(output))
+		}
+		return "", err
+	}
+
+	if packageAPIInput.Docs {
+		return output, nil
+	}
+
+	return declarationsOnly(output), nil
+}
+
+// declarationsOnly strips the documentation from 'go doc -all' output, leaving the package clause, section headings
+// and declarations. Declarations are printed unindented with their docs indented below, except for the package
+// documentation which comes unindented between the package clause and the first section heading.
+func declarationsOnly(output string) string {
+	var lines []string
+	inPackageDoc := false
+	for i, line := range strings.Split(output, "\n") {
+		switch {
+		case i == 0:
+			inPackageDoc = true
+		case goDocSections[line]:
+			inPackageDoc = false
+		case inPackageDoc && line != "" || strings.HasPrefix(line, "    "):
+			continue
+		}
+		// Dropping documentation leaves runs of blank lines, keep a single one between declarations
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
+
+// goDocSections are the headings 'go doc -all' groups declarations under
+var goDocSections = map[string]bool{
+	"CONSTANTS": true,
+	"VARIABLES": true,
+	"FUNCTIONS": true,
+	"TYPES":     true,
+}
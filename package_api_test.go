@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPackageAPIStdlib(t *testing.T) {
+	setupGoModule(t, nil)
+
+	got, err := callTool(t, PackageAPI, map[string]any{"import_path": "container/ring"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package ring", "TYPES", "type Ring struct", "func New(n int) *Ring", "func (r *Ring) Len() int"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("API is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "circular lists") || strings.Contains(got, "New creates a ring") {
+		t.Errorf("API includes documentation:\n%s", got)
+	}
+
+	got, err = callTool(t, PackageAPI, map[string]any{"import_path": "container/ring", "docs": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "New creates a ring of n elements.") {
+		t.Errorf("docs missing from:\n%s", got)
+	}
+}
+
+func TestPackageAPIBadImportPath(t *testing.T) {
+	setupGoModule(t, nil)
+
+	_, err := callTool(t, PackageAPI, map[string]any{"import_path": "example.com/does/not/exist"})
+	if err == nil || !strings.Contains(err.Error(), "go doc example.com/does/not/exist failed") {
+		t.Errorf("got error %v, want go doc's failure", err)
+	}
+
+	for _, importPath := range []string{"", "-all", "main.go"} {
+		if _, err := callTool(t, PackageAPI, map[string]any{"import_path": importPath}); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%q: got error %v, want ErrInvalidInput", importPath, err)
+		}
+	}
+
+	config.AllowCommands = false
+	if _, err := callTool(t, PackageAPI, map[string]any{"import_path": "fmt"}); !errors.Is(err, ErrCommandsDisabled) {
+		t.Errorf("got error %v, want ErrCommandsDisabled", err)
+	}
+}
+
+func TestDeclarationsOnly(t *testing.T) {
+	output := "package p // import \"example.com/p\"\n\nPackage p does things.\nIt has two lines.\n\nFUNCTIONS\n\nfunc A()\n    A does a.\n\n    More about A.\n\nfunc B()\n"
+
+	if got, want := declarationsOnly(output), "package p // import \"example.com/p\"\n\nFUNCTIONS\n\nfunc A()\n\nfunc B()\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
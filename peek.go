@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var PeekDefinition = ToolDefinition{
+	Name:        "peek",
+	Description: "Show the lines around each match of a regular expression in a file, with line numbers, instead of reading the whole file. Matching lines are marked with '>' and overlapping ranges are merged, separate ranges are divided by '--'. Use this to look at how something is used before reading or editing the exact lines.",
+	InputSchema: PeekInputSchema,
+	Function:    Peek,
+	Retries:     readToolRetries,
+}
+
+type PeekInput struct {
+	Path    string `json:"path" jsonschema_description:"The relative path of the file to search."`
+	Pattern string `json:"pattern" jsonschema_description:"A Go regular expression matched against each line."`
+	Context *int   `json:"context,omitempty" jsonschema_description:"How many lines to show before and after each match. Defaults to 3."`
+}
+
+var PeekInputSchema = GenerateSchema[PeekInput]()
+
+const (
+	defaultPeekContext = 3
+	// maxPeekMatches caps how many matches are shown so a pattern matching everything doesn't return the whole file
+	maxPeekMatches = 50
+)
+
+func Peek(input json.RawMessage) (string, error) {
+	peekInput := PeekInput{}
+	err := json.Unmarshal(input, &peekInput)
+	if err != nil {
+		return "", err
+	}
+
+	context := defaultPeekContext
+	if peekInput.Context != nil {
+		context = *peekInput.Context
+	}
+	if context < 0 {
+		return "", fmt.Errorf("context must not be negative: %w", ErrInvalidInput)
+	}
+
+	if peekInput.Pattern == "" {
+		return "", fmt.Errorf("pattern must not be empty: %w", ErrInvalidInput)
+	}
+	pattern, err := regexp.Compile(peekInput.Pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %v: %w", err, ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(peekInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := readTextFile(resolved, false)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	var matches []int
+	for i, line := range lines {
+		if pattern.MatchString(line) {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return fmt.Sprintf("no lines in %s match %s", peekInput.Path, peekInput.Pattern), nil
+	}
+
+	truncated := len(matches) > maxPeekMatches
+	if truncated {
+		matches = matches[:maxPeekMatches]
+	}
+
+	result := peekLines(lines, matches, context)
+	if truncated {
+		result += fmt.Sprintf("\n[only the first %d matches are shown, narrow the pattern to see others]", maxPeekMatches)
+	}
+
+	return result, nil
+}
+
+// peekLines renders the lines within context of each match, numbered from 1, merging ranges that touch or overlap
+func peekLines(lines []string, matches []int, context int) string {
+	width := len(fmt.Sprint(min(matches[len(matches)-1]+context+1, len(lines))))
+	matched := make(map[int]bool, len(matches))
+	for _, i := range matches {
+		matched[i] = true
+	}
+
+	var out []string
+	end := -1
+	for _, match := range matches {
+		start := max(match-context, 0)
+		if start <= end+1 {
+			start = end + 1
+		} else if end >= 0 {
+			out = append(out, "--")
+		}
+
+		end = max(end, min(match+context, len(lines)-1))
+		for i := start; i <= end; i++ {
+			marker := " "
+			if matched[i] {
+				marker = ">"
+			}
+			out = append(out, fmt.Sprintf("%s%*d: %s", marker, width, i+1, lines[i]))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPeek(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", numberedLines(12))
+
+	tests := []struct {
+		name    string
+		pattern string
+		context any
+		want    string
+	}{
+		{"one match", `^line 5$`, 1, " 4: line 4\n>5: line 5\n 6: line 6"},
+		{"separate ranges", `^line (2|9)$`, 1, "  1: line 1\n> 2: line 2\n  3: line 3\n--\n  8: line 8\n> 9: line 9\n 10: line 10"},
+		{"overlapping ranges", `^line [46]$`, 1, " 3: line 3\n>4: line 4\n 5: line 5\n>6: line 6\n 7: line 7"},
+		{"no context", `^line 1[12]$`, 0, ">11: line 11\n>12: line 12"},
+		{"clipped at the start", `^line 1$`, 2, ">1: line 1\n 2: line 2\n 3: line 3"},
+		{"default context", `^line 7$`, nil, "  4: line 4\n  5: line 5\n  6: line 6\n> 7: line 7\n  8: line 8\n  9: line 9\n 10: line 10"},
+		{"no matches", `missing`, 1, "no lines in file.txt match missing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := map[string]any{"path": "file.txt", "pattern": test.pattern}
+			if test.context != nil {
+				input["context"] = test.context
+			}
+
+			got, err := callTool(t, Peek, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPeekLimitsMatches(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", numberedLines(maxPeekMatches+10))
+
+	got, err := callTool(t, Peek, map[string]any{"path": "file.txt", "pattern": "line", "context": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(got, ">"); n != maxPeekMatches {
+		t.Errorf("showed %d matches, want %d", n, maxPeekMatches)
+	}
+	if !strings.HasSuffix(got, "[only the first 50 matches are shown, narrow the pattern to see others]") {
+		t.Errorf("got %q, want a truncation note", got)
+	}
+}
+
+func TestPeekRejections(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "file.txt", numberedLines(3))
+
+	for _, input := range []map[string]any{
+		{"path": "file.txt", "pattern": ""},
+		{"path": "file.txt", "pattern": "("},
+		{"path": "file.txt", "pattern": "line", "context": -1},
+	} {
+		if _, err := callTool(t, Peek, input); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%v: got error %v, want ErrInvalidInput", input, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// personaDir holds additional personas for --persona, as <name>.md, which take precedence over the built-in ones
+const personaDir = ".agent/personas"
+
+// personas are the built-in system prompt presets selectable with --persona
+var personas = map[string]string{
+	"concise-reviewer": "You are a concise code reviewer. Point out bugs, risky changes and unclear code, most important first. " +
+		"Keep each comment to a sentence or two, skip praise and don't restate what the code does.",
+	"pair-programmer": "You are a pair programmer working alongside the user. Think out loud briefly, make small changes one at a time, " +
+		"check them with the build and tests as you go, and ask before any change with a wide reach.",
+	"teacher": "You are a patient teacher. Explain the why behind each change and the concepts it relies on, " +
+		"and prefer guiding the user to the answer over simply handing it over.",
+}
+
+// personaPrompt returns the system prompt of the named persona, looking in personaDir before the built-in ones
+func personaPrompt(name string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(personaDir, name+".md"))
+	if err == nil {
+		return strings.TrimSpace(string(content)), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	prompt, ok := personas[name]
+	if !ok {
+		return "", fmt.Errorf("unknown persona %q, expected one of %s or a file in %s", name, personaNamesHelp(), personaDir)
+	}
+
+	return prompt, nil
+}
+
+func personaNamesHelp() string {
+	return strings.Join(slices.Sorted(maps.Keys(personas)), ", ")
+}
+
+// systemPrompt joins the parts of the system prompt in order: the persona, the --system text and the context files
+func systemPrompt(persona, system, contextFiles string) (string, error) {
+	var parts []string
+	if persona != "" {
+		prompt, err := personaPrompt(persona)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, prompt)
+	}
+	for _, part := range []string{system, contextFiles} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSystemPromptPersona(t *testing.T) {
+	setupWorkspace(t)
+
+	tests := []struct {
+		name    string
+		persona string
+		system  string
+		want    string
+	}{
+		{"none", "", "", ""},
+		{"system only", "", "  Use British spelling.\n", "Use British spelling."},
+		{"persona only", "teacher", "", personas["teacher"]},
+		{"persona then system", "concise-reviewer", "Focus on error handling.", personas["concise-reviewer"] + "\n\nFocus on error handling."},
+	}
+
+	for _, test := range tests {
+		got, err := systemPrompt(test.persona, test.system, "")
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	if _, err := systemPrompt("pirate", "", ""); err == nil || !strings.Contains(err.Error(), "concise-reviewer, pair-programmer, teacher") {
+		t.Errorf("got error %v, want the known personas listed", err)
+	}
+}
+
+func TestPersonaFromConfig(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, filepath.Join(personaDir, "pirate.md"), "\nTalk like a pirate.\n")
+	writeFile(t, filepath.Join(personaDir, "teacher.md"), "Teach with examples.")
+
+	for name, want := range map[string]string{"pirate": "Talk like a pirate.", "teacher": "Teach with examples."} {
+		if got, err := personaPrompt(name); err != nil || got != want {
+			t.Errorf("personaPrompt(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestPersonaSentAsSystemBlock(t *testing.T) {
+	setupWorkspace(t)
+
+	system, err := systemPrompt("pair-programmer", "The project uses Go.", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent any
+	client := fakeAPI(t, func(request map[string]any) string {
+		sent = request["system"]
+		return messageJSON(textBlock("Hi"))
+	})
+	agent := newTestAgent(client, nil)
+	agent.system = system
+	captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "hello"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	blocks, _ := sent.([]any)
+	if len(blocks) != 1 {
+		t.Fatalf("sent system %v, want one block", sent)
+	}
+	text, _ := blocks[0].(map[string]any)["text"].(string)
+	if want := personas["pair-programmer"] + "\n\nThe project uses Go."; text != want {
+		t.Errorf("system block is %q, want %q", text, want)
+	}
+}
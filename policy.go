@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tool policies selectable with --tool-policy
+const (
+	PolicyAllow = "allow"
+	PolicyAsk   = "ask"
+	PolicyDeny  = "deny"
+)
+
+// toolPolicy returns the policy configured for the named tool, allowing tools without one
+func toolPolicy(name string) string {
+	if policy, ok := config.ToolPolicies[name]; ok {
+		return policy
+	}
+
+	return PolicyAllow
+}
+
+// policyFlag parses name=policy pairs into target
+func policyFlag(target *map[string]string) func(string) error {
+	return func(value string) error {
+		name, policy, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("expected name=policy, got %q", value)
+		}
+		if policy != PolicyAllow && policy != PolicyAsk && policy != PolicyDeny {
+			return fmt.Errorf("unknown policy %q, expected %s, %s or %s", policy, PolicyAllow, PolicyAsk, PolicyDeny)
+		}
+
+		if *target == nil {
+			*target = map[string]string{}
+		}
+		(*target)[name] = policy
+		return nil
+	}
+}
+
+// skipPermissions turns every configured tool policy into allow and enables command execution
+func skipPermissions(cfg *Config) {
+	for name := range cfg.ToolPolicies {
+		cfg.ToolPolicies[name] = PolicyAllow
+	}
+	cfg.AllowCommands = true
+}
+
+// confirmSkipPermissions prints the full-auto warning banner to out and, unless --yes was given, asks the user
+// to confirm, reporting whether the session may go ahead
+func confirmSkipPermissions(out io.Writer, colors bool, getUserMessage func() (string, bool)) bool {
+	banner := "WARNING: --dangerously-skip-permissions is set. Every tool runs without asking, including commands."
+	if colors {
+		banner = ANSI_RED + banner + ANSI_RESET
+	}
+	fmt.Fprintln(out, banner)
+
+	if config.Yes {
+		return true
+	}
+
+	fmt.Fprint(out, "Continue? [y/N]: ")
+	answer, ok := getUserMessage()
+	if !ok {
+		fmt.Fprintln(out)
+	}
+
+	return ok && isYes(answer)
+}
+
+// isYes reports whether a typed answer means yes
+func isYes(answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// approveTool asks the user whether a tool with the ask policy may run
+func (a *Agent) approveTool(name string) bool {
+	a.questionPrompt("", "Allow "+name+"? [y/N]")
+	answer, ok := a.readAnswer()
+
+	return ok && isYes(answer)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSkipPermissionsFlag(t *testing.T) {
+	cfg, err := ParseFlags([]string{"--tool-policy", "bash=deny", "--tool-policy", "edit_file=ask", "--dangerously-skip-permissions"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cfg.AllowCommands {
+		t.Error("commands are not enabled")
+	}
+	for name, policy := range cfg.ToolPolicies {
+		if policy != PolicyAllow {
+			t.Errorf("%s policy is %s, want allow", name, policy)
+		}
+	}
+	if cfg.Yes {
+		t.Error("--yes was set without being given")
+	}
+
+	cfg, err = ParseFlags([]string{"--tool-policy", "bash=deny"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AllowCommands || cfg.ToolPolicies["bash"] != PolicyDeny {
+		t.Error("policies changed without --dangerously-skip-permissions")
+	}
+}
+
+func TestConfirmSkipPermissions(t *testing.T) {
+	tests := []struct {
+		name   string
+		yes    bool
+		answer []string
+		want   bool
+	}{
+		{name: "confirmed", answer: []string{"y"}, want: true},
+		{name: "declined", answer: []string{"n"}, want: false},
+		{name: "end of input", answer: nil, want: false},
+		{name: "yes flag", yes: true, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			config.Yes = test.yes
+
+			var out bytes.Buffer
+			if got := confirmSkipPermissions(&out, true, scriptedInput(test.answer...)); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			if !strings.Contains(out.String(), ANSI_RED+"WARNING: --dangerously-skip-permissions is set") {
+				t.Errorf("no red banner in %q", out.String())
+			}
+			if test.yes && strings.Contains(out.String(), "Continue?") {
+				t.Error("asked to confirm despite --yes")
+			}
+		})
+	}
+}
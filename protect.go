@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// safeModePatterns are protected by --safe-mode on top of any --protect globs
+var safeModePatterns = []string{
+	".env",
+	".env.*",
+	"*.pem",
+	"*.key",
+	"go.sum",
+	".github/**",
+	".gitlab-ci.yml",
+}
+
+// protectedPattern returns the --protect glob matching a tool supplied path, or an empty string when the path
+// may be changed. Globs without a slash match the file name at any depth, others the path from the workspace root.
+// Where the path goes through a symlink, the file it leads to is checked as well, so a link can't expose a
+// protected file.
+func protectedPattern(p string) string {
+	if len(config.ProtectedPaths) == 0 {
+		return ""
+	}
+
+	resolved, err := resolvePath(p)
+	if err != nil {
+		return ""
+	}
+	root, err := workspaceRoot()
+	if err != nil {
+		return ""
+	}
+	relPaths := []string{}
+	if relPath, err := filepath.Rel(root, resolved); err == nil {
+		relPaths = append(relPaths, relPath)
+	}
+	if real, err := realPath(resolved); err == nil {
+		if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+			if relPath, err := filepath.Rel(realRoot, real); err == nil {
+				relPaths = append(relPaths, relPath)
+			}
+		}
+	}
+
+	for _, relPath := range relPaths {
+		relPath = filepath.ToSlash(relPath)
+		for _, pattern := range config.ProtectedPaths {
+			if strings.Contains(pattern, "/") && matchGlob(pattern, relPath) || matchGlob(pattern, path.Base(relPath)) {
+				return pattern
+			}
+		}
+	}
+
+	return ""
+}
+
+// realPath follows the symlinks in p, including in the directory of a file that doesn't exist yet
+func realPath(p string) (string, error) {
+	real, err := filepath.EvalSymlinks(p)
+	if err == nil || !os.IsNotExist(err) {
+		return real, err
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(p))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, filepath.Base(p)), nil
+}
+
+// protectedError explains that a mutating tool was refused because of a --protect glob
+func protectedError(p, pattern string) error {
+	return fmt.Errorf("%s is protected by %q and can't be changed, ask the user to change it instead", p, pattern)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProtectedPattern(t *testing.T) {
+	setupWorkspace(t)
+	config.ProtectedPaths = []string{"*.pem", ".github/**", "go.sum"}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"certs/server.pem", "*.pem"},
+		{".github/workflows/ci.yml", ".github/**"},
+		{"./go.sum", "go.sum"},
+		{"vendor/go.sum", "go.sum"},
+		{"main.go", ""},
+		{"docs/.github/notes.md", ""},
+	}
+
+	for _, test := range tests {
+		if got := protectedPattern(test.path); got != test.want {
+			t.Errorf("protectedPattern(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestSafeModeProtects(t *testing.T) {
+	cfg, err := ParseFlags([]string{"--safe-mode", "--protect", "*.sql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pattern := range append([]string{"*.sql"}, safeModePatterns...) {
+		if !slices.Contains(cfg.ProtectedPaths, pattern) {
+			t.Errorf("protected paths %v are missing %q", cfg.ProtectedPaths, pattern)
+		}
+	}
+}
+
+func TestProtectedEditRefused(t *testing.T) {
+	setupWorkspace(t)
+	config.ProtectedPaths = []string{".env"}
+	config.SkipPermissions = true
+	writeFile(t, ".env", "SECRET=1\n")
+	writeFile(t, "main.go", "package main\n")
+	agent := newTestAgent(nil, []ToolDefinition{EditFileDefinition})
+
+	captureStdout(t, func() {
+		content, isError := agent.runTool("tool_1", "edit_file", json.RawMessage(`{"path":".env","old_str":"1","new_str":"2"}`)).content()
+		if !isError || !strings.Contains(content, `protected by ".env"`) {
+			t.Errorf("protected file: got %q, want a protected file error", content)
+		}
+		if content, isError := agent.runTool("tool_2", "edit_file", json.RawMessage(`{"path":"main.go","old_str":"main","new_str":"app"}`)).content(); isError {
+			t.Errorf("ordinary file: got error %q", content)
+		}
+	})
+
+	if got := readFile(t, ".env"); got != "SECRET=1\n" {
+		t.Errorf(".env changed to %q", got)
+	}
+	if got := readFile(t, "main.go"); got != "package app\n" {
+		t.Errorf("main.go has %q", got)
+	}
+}
+
+func TestProtectedRenameRefused(t *testing.T) {
+	setupRenameModule(t)
+	config.ProtectedPaths = []string{"other.go"}
+	agent := newTestAgent(nil, []ToolDefinition{RenameSymbolDefinition})
+
+	captureStdout(t, func() {
+		content, isError := agent.runTool("tool_1", "rename_symbol", json.RawMessage(`{"file":"greet.go","line":3,"column":6,"new_name":"SayHello"}`)).content()
+		if !isError || !strings.Contains(content, "other.go is protected") {
+			t.Errorf("got %q, want other.go reported as protected", content)
+		}
+	})
+
+	for _, name := range []string{"greet.go", "main.go", "other.go"} {
+		if strings.Contains(readFile(t, name), "SayHello") {
+			t.Errorf("%s was renamed although the rename touches a protected file", name)
+		}
+	}
+}
+
+func TestProtectedThroughSymlink(t *testing.T) {
+	setupWorkspace(t)
+	config.ProtectedPaths = []string{".env", ".github/**"}
+	config.SkipPermissions = true
+	writeFile(t, ".env", "SECRET=1\n")
+	writeFile(t, ".github/workflows/ci.yml", "on: push\n")
+	for link, target := range map[string]string{"settings": ".env", "ci": ".github/workflows"} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+	agent := newTestAgent(nil, []ToolDefinition{EditFileDefinition, DeleteFileDefinition, CreateFileDefinition})
+
+	calls := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"edit_file", `{"path":"settings","old_str":"1","new_str":"2"}`, `settings is protected by ".env"`},
+		{"delete_file", `{"path":"settings"}`, `settings is protected by ".env"`},
+		{"edit_file", `{"path":"ci/ci.yml","old_str":"push","new_str":"pull_request"}`, `ci/ci.yml is protected by ".github/**"`},
+		{"create_file", `{"path":"ci/release.yml","content":"on: tag\n"}`, `ci/release.yml is protected by ".github/**"`},
+	}
+
+	captureStdout(t, func() {
+		for i, call := range calls {
+			content, isError := agent.runTool(fmt.Sprintf("tool_%d", i), call.name, json.RawMessage(call.input)).content()
+			if !isError || !strings.Contains(content, call.want) {
+				t.Errorf("%s %s: got %q, want %q", call.name, call.input, content, call.want)
+			}
+		}
+	})
+
+	if got := readFile(t, ".env"); got != "SECRET=1\n" {
+		t.Errorf(".env changed to %q", got)
+	}
+	if got := readFile(t, ".github/workflows/ci.yml"); got != "on: push\n" {
+		t.Errorf("ci.yml changed to %q", got)
+	}
+	if _, err := os.Stat(".github/workflows/release.yml"); err == nil {
+		t.Error("release.yml was created in a protected directory")
+	}
+}
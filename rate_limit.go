@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket allowing bursts of up to a minute's worth of requests, refilled evenly over time
+type rateLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	interval time.Duration
+	last     time.Time
+
+	// now and after are the clock, swappable so pacing can be checked without waiting
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// newRateLimiter returns a limiter allowing perMinute requests a minute
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		interval: time.Minute / time.Duration(perMinute),
+		last:     time.Now(),
+		now:      time.Now,
+		after:    time.After,
+	}
+}
+
+// Wait blocks until a request may be made, or returns the context's error if it is cancelled first
+func (r *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait := r.reserve()
+		if wait == 0 {
+			return nil
+		}
+
+		select {
+		case <-r.after(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes a token if one is available, otherwise returning how long until the next one is
+func (r *rateLimiter) reserve() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.tokens = min(r.capacity, r.tokens+float64(now.Sub(r.last))/float64(r.interval))
+	r.last = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - r.tokens) * float64(r.interval))
+}
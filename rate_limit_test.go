@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when the limiter waits on it
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) install(r *rateLimiter) {
+	r.now = func() time.Time { return c.now }
+	r.last = c.now
+	r.after = func(d time.Duration) <-chan time.Time {
+		c.waits = append(c.waits, d)
+		c.now = c.now.Add(d)
+		fired := make(chan time.Time, 1)
+		fired <- c.now
+		return fired
+	}
+}
+
+func TestRateLimiterPacesRequests(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := newRateLimiter(60)
+	clock.install(limiter)
+	start := clock.now
+
+	// A full bucket lets a minute's worth through at once
+	for i := 0; i < 60; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(clock.waits) != 0 {
+		t.Fatalf("the first 60 requests waited %v", clock.waits)
+	}
+
+	// After that requests are paced at one a second
+	for i := 0; i < 5; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := clock.now.Sub(start); elapsed != 5*time.Second {
+		t.Errorf("5 more requests took %s, want 5s", elapsed)
+	}
+	for _, wait := range clock.waits {
+		if wait != time.Second {
+			t.Errorf("waited %s, want 1s", wait)
+		}
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := newRateLimiter(2)
+	clock.install(limiter)
+
+	limiter.Wait(context.Background())
+	limiter.Wait(context.Background())
+
+	// Half a minute later one token has come back
+	clock.now = clock.now.Add(30 * time.Second)
+	if wait := limiter.reserve(); wait != 0 {
+		t.Errorf("waited %s after the refill, want none", wait)
+	}
+	if wait := limiter.reserve(); wait != 30*time.Second {
+		t.Errorf("next wait is %s, want 30s", wait)
+	}
+}
+
+func TestRateLimiterCancelled(t *testing.T) {
+	limiter := newRateLimiter(1)
+	limiter.after = func(time.Duration) <-chan time.Time { return nil }
+	limiter.Wait(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
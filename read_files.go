@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+var ReadFilesDefinition = ToolDefinition{
+	Name:        "read_files",
+	Description: "Read the contents of several files at once. Use this instead of multiple read_file calls when you already know which files you need. Returns a JSON object mapping each path to its content, or to an error if that file could not be read.",
+	InputSchema: ReadFilesInputSchema,
+	Function:    ReadFiles,
+	Retries:     readToolRetries,
+}
+
+type ReadFilesInput struct {
+	Paths      []string `json:"paths" jsonschema_description:"The relative paths of the files to read."`
+	AllowLarge bool     `json:"allow_large,omitempty" jsonschema_description:"Read files even if they exceed the maximum file size."`
+}
+
+var ReadFilesInputSchema = GenerateSchema[ReadFilesInput]()
+
+type readFilesResult struct {
+	Content string `json:"content,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func ReadFiles(input json.RawMessage) (string, error) {
+	readFilesInput := ReadFilesInput{}
+	err := json.Unmarshal(input, &readFilesInput)
+	if err != nil {
+		return "", err
+	}
+
+	if len(readFilesInput.Paths) == 0 {
+		return "", fmt.Errorf("no paths given: %w", ErrInvalidInput)
+	}
+
+	// A failure reading one file is reported against its path rather than failing the whole batch
+	results := make(map[string]readFilesResult, len(readFilesInput.Paths))
+	for _, filePath := range readFilesInput.Paths {
+		content, err := readSandboxedTextFile(filePath, readFilesInput.AllowLarge)
+		if err != nil {
+			results[filePath] = readFilesResult{Error: err.Error()}
+			continue
+		}
+		results[filePath] = readFilesResult{Content: content}
+	}
+
+	result, err := json.Marshal(results)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+func readSandboxedTextFile(filePath string, allowLarge bool) (string, error) {
+	resolved, err := resolvePath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return readTextFile(resolved, allowLarge)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestReadFilesMixed(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "a.txt", "alpha")
+	writeFile(t, "dir/b.txt", "beta")
+	writeFile(t, "binary.bin", "\x00\x01")
+
+	got, err := callTool(t, ReadFiles, map[string]any{"paths": []string{"a.txt", "dir/b.txt", "missing.txt", "binary.bin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results map[string]readFilesResult
+	if err := json.Unmarshal([]byte(got), &results); err != nil {
+		t.Fatal(err)
+	}
+
+	if results["a.txt"].Content != "alpha" || results["dir/b.txt"].Content != "beta" {
+		t.Errorf("existing files not read: %s", got)
+	}
+	if results["missing.txt"].Error == "" || results["missing.txt"].Content != "" {
+		t.Errorf("missing file has no error: %s", got)
+	}
+	if results["binary.bin"].Error == "" {
+		t.Errorf("binary file was not refused: %s", got)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"os"
+	"strings"
+)
+
+var ReadFunctionDefinition = ToolDefinition{
+	Name:        "read_function",
+	Description: "Read the source of a single function or method from a Go file, including its doc comment, along with its line range. Use 'Type.Method' to pick a method when the name alone is ambiguous.",
+	InputSchema: ReadFunctionInputSchema,
+	Function:    ReadFunction,
+	Retries:     readToolRetries,
+}
+
+type ReadFunctionInput struct {
+	Path string `json:"path" jsonschema_description:"The relative path of a Go source file."`
+	Name string `json:"name" jsonschema_description:"The function name, or 'Type.Method' for a method, e.g. 'NewAgent' or 'Agent.Run'."`
+}
+
+var ReadFunctionInputSchema = GenerateSchema[ReadFunctionInput]()
+
+func ReadFunction(input json.RawMessage) (string, error) {
+	readFunctionInput := ReadFunctionInput{}
+	err := json.Unmarshal(input, &readFunctionInput)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(readFunctionInput.Name)
+	if name == "" {
+		return "", fmt.Errorf("name must not be empty: %w", ErrInvalidInput)
+	}
+	receiver, name, qualified := strings.Cut(name, ".")
+	if !qualified {
+		name, receiver = receiver, ""
+	}
+
+	fset, file, err := parseGoFile(readFunctionInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	var matches []*ast.FuncDecl
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Name.Name != name {
+			continue
+		}
+		if qualified && receiverTypeName(funcDecl) != receiver {
+			continue
+		}
+		matches = append(matches, funcDecl)
+	}
+
+	switch {
+	case len(matches) == 0:
+		return "", fmt.Errorf("%s not found in %s: %w", readFunctionInput.Name, readFunctionInput.Path, ErrNotFound)
+	case len(matches) > 1:
+		return "", fmt.Errorf("%s is declared %d times in %s, qualify it as Type.%s: %w", name, len(matches), readFunctionInput.Path, name, ErrMultipleMatches)
+	}
+
+	resolved, err := resolvePath(readFunctionInput.Path)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	decl := matches[0]
+	start := fset.Position(decl.Pos())
+	if decl.Doc != nil {
+		start = fset.Position(decl.Doc.Pos())
+	}
+	end := fset.Position(decl.End())
+
+	return fmt.Sprintf("%s:%d-%d\n%s", readFunctionInput.Path, start.Line, end.Line, content[start.Offset:end.Offset]), nil
+}
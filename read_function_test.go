@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+const readFunctionSource = `package shapes
+
+type Square struct{ Side int }
+
+type Circle struct{ Radius int }
+
+// Area returns the area of the square
+func (s Square) Area() int {
+	return s.Side * s.Side
+}
+
+func (c *Circle) Area() int {
+	return 3 * c.Radius * c.Radius
+}
+
+func NewSquare(side int) Square {
+	return Square{Side: side}
+}
+`
+
+func TestReadFunction(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "NewSquare", want: "shapes.go:16-18\nfunc NewSquare(side int) Square {\n\treturn Square{Side: side}\n}"},
+		{name: "Square.Area", want: "shapes.go:7-10\n// Area returns the area of the square\nfunc (s Square) Area() int {\n\treturn s.Side * s.Side\n}"},
+		{name: "Circle.Area", want: "shapes.go:12-14\nfunc (c *Circle) Area() int {\n\treturn 3 * c.Radius * c.Radius\n}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "shapes.go", readFunctionSource)
+
+			got, err := callTool(t, ReadFunction, map[string]any{"path": "shapes.go", "name": test.name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadFunctionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		want error
+	}{
+		{name: "Missing", want: ErrNotFound},
+		{name: "Square.NewSquare", want: ErrNotFound},
+		{name: "Area", want: ErrMultipleMatches},
+		{name: " ", want: ErrInvalidInput},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "shapes.go", readFunctionSource)
+
+			_, err := callTool(t, ReadFunction, map[string]any{"path": "shapes.go", "name": test.name})
+			if !errors.Is(err, test.want) {
+				t.Errorf("got error %v, want %v", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+var RecentFilesDefinition = ToolDefinition{
+	Name:        "recent_files",
+	Description: "List the most recently modified files in the workspace, newest first, with their size and modification time as JSON. Files ignored by .gitignore are skipped. A good first step to find what was being worked on.",
+	InputSchema: RecentFilesInputSchema,
+	Function:    RecentFiles,
+	Retries:     readToolRetries,
+}
+
+type RecentFilesInput struct {
+	Limit int `json:"limit,omitempty" jsonschema_description:"How many files to return. Defaults to 10."`
+}
+
+var RecentFilesInputSchema = GenerateSchema[RecentFilesInput]()
+
+const defaultRecentFiles = 10
+
+func RecentFiles(input json.RawMessage) (string, error) {
+	recentFilesInput := RecentFilesInput{}
+	err := json.Unmarshal(input, &recentFilesInput)
+	if err != nil {
+		return "", err
+	}
+
+	limit := recentFilesInput.Limit
+	if limit == 0 {
+		limit = defaultRecentFiles
+	}
+	if limit < 0 {
+		return "", fmt.Errorf("limit must be positive: %w", ErrInvalidInput)
+	}
+
+	files, err := globFiles("**")
+	if err != nil {
+		return "", err
+	}
+
+	entries := []fileEntry{}
+	for _, file := range files {
+		resolved, err := resolvePath(filepath.FromSlash(file))
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(resolved)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		entries = append(entries, fileEntry{Path: file, Size: info.Size(), ModifiedTime: info.ModTime()})
+	}
+
+	// Newest first, with the path breaking ties so the order is stable
+	slices.SortFunc(entries, func(a, b fileEntry) int {
+		if c := b.ModifiedTime.Compare(a.ModifiedTime); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	entries = entries[:min(limit, len(entries))]
+	for i := range entries {
+		entries[i].ModifiedTime = entries[i].ModifiedTime.UTC().Truncate(time.Second)
+	}
+
+	result, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
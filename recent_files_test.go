@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+// setupRecentFiles creates a tree whose files were modified a minute apart, in the order given
+func setupRecentFiles(t *testing.T, names ...string) time.Time {
+	t.Helper()
+
+	setupWorkspace(t)
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, name := range names {
+		writeFile(t, name, name)
+		modified := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(name, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return base
+}
+
+// recentPaths runs recent_files and returns the paths it listed
+func recentPaths(t *testing.T, input map[string]any) []fileEntry {
+	t.Helper()
+
+	got, err := callTool(t, RecentFiles, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []fileEntry
+	if err := json.Unmarshal([]byte(got), &entries); err != nil {
+		t.Fatalf("output %s: %v", got, err)
+	}
+
+	return entries
+}
+
+func TestRecentFilesOrdering(t *testing.T) {
+	base := setupRecentFiles(t, "old.go", "pkg/middle.go", "docs/new.md", "newest.txt")
+
+	entries := recentPaths(t, map[string]any{})
+	var paths []string
+	for _, entry := range entries {
+		paths = append(paths, entry.Path)
+	}
+	if want := []string{"newest.txt", "docs/new.md", "pkg/middle.go", "old.go"}; !slices.Equal(paths, want) {
+		t.Errorf("got %v, want %v", paths, want)
+	}
+	if want := (fileEntry{Path: "newest.txt", Size: 10, ModifiedTime: base.Add(3 * time.Minute)}); entries[0] != want {
+		t.Errorf("newest entry is %+v, want %+v", entries[0], want)
+	}
+
+	if limited := recentPaths(t, map[string]any{"limit": 2}); len(limited) != 2 || limited[1].Path != "docs/new.md" {
+		t.Errorf("limit 2 returned %+v", limited)
+	}
+}
+
+func TestRecentFilesSkipsIgnored(t *testing.T) {
+	setupRecentFiles(t, "main.go", ".gitignore", "build/output.bin", ".git/HEAD")
+	writeFile(t, ".gitignore", "build/\n")
+
+	var paths []string
+	for _, entry := range recentPaths(t, map[string]any{}) {
+		paths = append(paths, entry.Path)
+	}
+	if want := []string{".gitignore", "main.go"}; !slices.Equal(paths, want) {
+		t.Errorf("got %v, want %v", paths, want)
+	}
+}
+
+func TestRecentFilesDefaultLimit(t *testing.T) {
+	var names []string
+	for _, name := range "abcdefghijkl" {
+		names = append(names, string(name)+".txt")
+	}
+	setupRecentFiles(t, names...)
+
+	if entries := recentPaths(t, map[string]any{}); len(entries) != defaultRecentFiles || entries[0].Path != "l.txt" {
+		t.Errorf("got %+v, want the %d newest files", entries, defaultRecentFiles)
+	}
+	if _, err := callTool(t, RecentFiles, map[string]any{"limit": -1}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("got error %v, want ErrInvalidInput", err)
+	}
+}
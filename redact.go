@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+)
+
+const redactedPlaceholder = "[REDACTED]"
+
+// defaultRedactPatterns match common secret shapes
+var defaultRedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]{10,}`),
+	regexp.MustCompile(`sk-[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}\b`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+}
+
+// keyValueSecretPattern matches key=value style secrets, its group keeping the key so the output still shows
+// which key was set
+var keyValueSecretPattern = regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key)\\?"?\s*[=:]\s*\\?"?)[^\s"'\\,&]+`)
+
+// redactSecrets masks anything matching the default or configured secret patterns. Configured patterns mask their
+// whole match, as any group they have may well be the secret itself.
+func redactSecrets(text string) string {
+	text = keyValueSecretPattern.ReplaceAllString(text, "${1}"+redactedPlaceholder)
+	for _, pattern := range slices.Concat(defaultRedactPatterns, config.RedactPatterns) {
+		text = pattern.ReplaceAllLiteralString(text, redactedPlaceholder)
+	}
+
+	return text
+}
+
+// redactJSON masks secrets in a JSON document, quoting the result as a string if masking broke its structure
+func redactJSON(data json.RawMessage) json.RawMessage {
+	redacted := redactSecrets(string(data))
+	if json.Valid([]byte(redacted)) {
+		return json.RawMessage(redacted)
+	}
+
+	quoted, _ := json.Marshal(redacted)
+	return quoted
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var RenameSymbolDefinition = ToolDefinition{
+	Name:        "rename_symbol",
+	Description: "Safely rename a Go identifier and every reference to it across the package and its dependents using gopls. Point at the identifier with its file, line and column. Prefer this over edit_file for renames, which can't tell an identifier apart from a substring of another. Returns the list of modified files.",
+	InputSchema: RenameSymbolInputSchema,
+	Function:    RenameSymbol,
+	Targets:     RenameSymbolTargets,
+	Timeout:     commandToolTimeout,
+	Mutates:     true,
+}
+
+type RenameSymbolInput struct {
+	File    string `json:"file" jsonschema_description:"The relative path of a Go file containing the identifier."`
+	Line    int    `json:"line" jsonschema_description:"The 1-based line of the identifier."`
+	Column  int    `json:"column" jsonschema_description:"The 1-based column (in bytes) of the start of the identifier."`
+	NewName string `json:"new_name" jsonschema_description:"The new name for the identifier."`
+}
+
+var RenameSymbolInputSchema = GenerateSchema[RenameSymbolInput]()
+
+func RenameSymbol(input json.RawMessage) (string, error) {
+	// gopls writes the files itself, so they are backed up before and formatted after, as any other edit is
+	if config.Backup {
+		targets, err := goplsRename(input, false)
+		if err != nil {
+			return "", err
+		}
+		for _, target := range targets {
+			resolved, err := resolvePath(target)
+			if err != nil {
+				return "", err
+			}
+			err = backupFile(resolved)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	modified, err := goplsRename(input, true)
+	if err != nil {
+		return "", err
+	}
+
+	note := ""
+	for _, file := range modified {
+		resolved, err := resolvePath(file)
+		if err != nil {
+			return "", err
+		}
+		formatted, err := autoFormatFile(resolved)
+		if err != nil {
+			return "", err
+		}
+		if formatted {
+			note = autoFormatNote
+		}
+	}
+
+	result, err := json.Marshal(modified)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result) + note, nil
+}
+
+// RenameSymbolTargets lists the files a rename would rewrite, without rewriting them
+func RenameSymbolTargets(input json.RawMessage) ([]string, error) {
+	return goplsRename(input, false)
+}
+
+// goplsRename runs gopls rename, writing the changes only when write is set, and returns the workspace relative
+// paths of the files it changes
+func goplsRename(input json.RawMessage, write bool) ([]string, error) {
+	renameSymbolInput := RenameSymbolInput{}
+	err := json.Unmarshal(input, &renameSymbolInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if renameSymbolInput.Line < 1 || renameSymbolInput.Column < 1 || !token.IsIdentifier(renameSymbolInput.NewName) {
+		return nil, fmt.Errorf("line and column must be positive and new_name a valid Go identifier: %w", ErrInvalidInput)
+	}
+
+	file, err := resolvePath(renameSymbolInput.File)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := exec.LookPath("gopls"); err != nil {
+		return nil, fmt.Errorf("gopls is required for renaming but was not found; install it with 'go install golang.org/x/tools/gopls@latest'")
+	}
+
+	position := fmt.Sprintf("%s:%d:%d", file, renameSymbolInput.Line, renameSymbolInput.Column)
+	args := []string{"rename", "-l", position, renameSymbolInput.NewName}
+	if write {
+		args = []string{"rename", "-w", "-l", position, renameSymbolInput.NewName}
+	}
+	output, err := runCommand("gopls", args...)
+	if err != nil {
+		if output != "" {
+			return nil, fmt.Errorf("rename failed: %s", strings.TrimSpace(output))
+		}
+		return nil, err
+	}
+
+	// gopls lists the absolute paths of the files it changes, one per line
+	root, err := resolvePath(".")
+	if err != nil {
+		return nil, err
+	}
+	modified := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(root, line); err == nil {
+			line = rel
+		}
+		modified = append(modified, line)
+	}
+
+	return modified, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeGopls stands in for 'gopls rename [-w] -l file:line:column new_name' within a single directory, renaming
+// the whole word under the cursor in every Go file that mentions it
+const fakeGopls = `#!/bin/sh
+shift
+write=false
+if [ "$1" = "-w" ]; then write=true; shift; fi
+shift
+position=$1 new=$2
+file=${position%:*:*}
+rest=${position#"$file":}
+line=${rest%:*} column=${rest#*:}
+old=$(sed -n "${line}p" "$file" | cut -c"${column}"- | grep -oE '^[A-Za-z_][A-Za-z0-9_]*')
+[ -n "$old" ] || { echo "no identifier at $position"; exit 1; }
+for f in $(grep -lw "$old" "$(dirname "$file")"/*.go); do
+	if $write; then sed -i "s/\b$old\b/$new/g" "$f"; fi
+	echo "$f"
+done
+`
+
+// useGopls makes gopls available to the test, installing fakeGopls on the PATH when the real one isn't there
+func useGopls(t *testing.T) {
+	t.Helper()
+
+	if _, err := exec.LookPath("gopls"); err == nil {
+		return
+	}
+
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "gopls"), []byte(fakeGopls), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// setupRenameModule creates a module whose Greet function is declared in one file and called from two others
+func setupRenameModule(t *testing.T) {
+	t.Helper()
+
+	setupGoModule(t, map[string]string{
+		"greet.go": "package main\n\nfunc Greet() string {\n\treturn \"hi\"\n}\n\nfunc Greeting() string {\n\treturn Greet()\n}\n",
+		"main.go":  "package main\n\nfunc main() {\n\tprintln(Greet())\n}\n",
+		"other.go": "package main\n\nvar message = Greet()\n",
+	})
+	config.CommandTimeout = 2 * time.Minute
+	useGopls(t)
+}
+
+func TestRenameSymbol(t *testing.T) {
+	setupRenameModule(t)
+
+	got, err := callTool(t, RenameSymbol, map[string]any{"file": "greet.go", "line": 3, "column": 6, "new_name": "SayHello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var modified []string
+	if err := json.Unmarshal([]byte(got), &modified); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(modified)
+	if want := []string{"greet.go", "main.go", "other.go"}; !slices.Equal(modified, want) {
+		t.Errorf("modified %v, want %v", modified, want)
+	}
+
+	for _, name := range []string{"greet.go", "main.go", "other.go"} {
+		content := readFile(t, name)
+		if !strings.Contains(content, "SayHello()") || strings.Contains(content, "Greet()") {
+			t.Errorf("%s was not renamed:\n%s", name, content)
+		}
+	}
+	// Greeting contains the old name but is a different identifier
+	if !strings.Contains(readFile(t, "greet.go"), "func Greeting()") {
+		t.Error("Greeting was renamed along with Greet")
+	}
+}
+
+func TestRenameSymbolInvalidInput(t *testing.T) {
+	setupRenameModule(t)
+
+	for _, input := range []map[string]any{
+		{"file": "greet.go", "line": 3, "column": 6, "new_name": "not an identifier"},
+		{"file": "greet.go", "line": 0, "column": 6, "new_name": "SayHello"},
+	} {
+		if _, err := callTool(t, RenameSymbol, input); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("input %v: got error %v, want ErrInvalidInput", input, err)
+		}
+	}
+}
+
+func TestRenameSymbolRequiresCommands(t *testing.T) {
+	setupRenameModule(t)
+	config.AllowCommands = false
+
+	_, err := callTool(t, RenameSymbol, map[string]any{"file": "greet.go", "line": 3, "column": 6, "new_name": "SayHello"})
+	if !errors.Is(err, ErrCommandsDisabled) {
+		t.Errorf("got error %v, want ErrCommandsDisabled", err)
+	}
+	if strings.Contains(readFile(t, "main.go"), "SayHello") {
+		t.Error("rename ran with commands disabled")
+	}
+}
+
+func TestRenameSymbolBackupAndFormat(t *testing.T) {
+	setupRenameModule(t)
+	config.Backup = true
+	config.AutoFormat = true
+	before := "package main\n\nvar (\n\tgreeting = Greet()\n\tcount    = 1\n)\n"
+	writeFile(t, "vars.go", before)
+
+	if _, err := callTool(t, RenameSymbol, map[string]any{"file": "vars.go", "line": 5, "column": 2, "new_name": "greetingCount"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, "vars.go.bak"); got != before {
+		t.Errorf("vars.go.bak has %q, want the file before the rename", got)
+	}
+	if got, want := readFile(t, "vars.go"), "package main\n\nvar (\n\tgreeting      = Greet()\n\tgreetingCount = 1\n)\n"; got != want {
+		t.Errorf("vars.go has %q, want it formatted %q", got, want)
+	}
+	if _, err := os.Stat("main.go.bak"); err == nil {
+		t.Error("backed up main.go, which the rename doesn't change")
+	}
+}
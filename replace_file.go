@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var ReplaceFileDefinition = ToolDefinition{
+	Name:        "replace_file",
+	Description: "Overwrite a file with entirely new content, creating it if it doesn't exist. Use this to rewrite a small file completely; prefer edit_file for targeted changes to part of a file.",
+	InputSchema: ReplaceFileInputSchema,
+	Function:    ReplaceFile,
+	Mutates:     true,
+}
+
+type ReplaceFileInput struct {
+	Path    string `json:"path" jsonschema_description:"The relative path of the file to write."`
+	Content string `json:"content" jsonschema_description:"The complete new content of the file."`
+}
+
+var ReplaceFileInputSchema = GenerateSchema[ReplaceFileInput]()
+
+func ReplaceFile(input json.RawMessage) (string, error) {
+	replaceFileInput := ReplaceFileInput{}
+	err := json.Unmarshal(input, &replaceFileInput)
+	if err != nil {
+		return "", err
+	}
+
+	if replaceFileInput.Path == "" {
+		return "", fmt.Errorf("invalid input parameters: %w", ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(replaceFileInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the permissions of an existing file, new files get the usual defaults
+	perm := os.FileMode(0644)
+	info, err := os.Stat(resolved)
+	switch {
+	case err == nil && info.IsDir():
+		return "", fmt.Errorf("%s is a directory: %w", replaceFileInput.Path, ErrInvalidInput)
+	case err == nil:
+		perm = info.Mode().Perm()
+	case os.IsNotExist(err):
+		err = os.MkdirAll(filepath.Dir(resolved), 0755)
+		if err != nil {
+			return "", fmt.Errorf("failed to create directory: %w", err)
+		}
+	default:
+		return "", err
+	}
+
+	err = backupFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	formatted, note := autoFormat(resolved, []byte(replaceFileInput.Content))
+	err = writeFileAtomic(resolved, formatted, perm)
+	if err != nil {
+		return "", err
+	}
+
+	if info == nil {
+		return fmt.Sprintf("Created %s%s", replaceFileInput.Path, note), nil
+	}
+	return fmt.Sprintf("Replaced the contents of %s%s", replaceFileInput.Path, note), nil
+}
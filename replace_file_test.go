@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestReplaceFileExisting(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "script.sh", "echo old\n")
+	if err := os.Chmod("script.sh", 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := callTool(t, ReplaceFile, map[string]any{"path": "script.sh", "content": "echo new\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Replaced the contents of script.sh" {
+		t.Errorf("got %q", got)
+	}
+	if content := readFile(t, "script.sh"); content != "echo new\n" {
+		t.Errorf("file has %q", content)
+	}
+
+	info, err := os.Stat("script.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("permissions changed to %v", info.Mode().Perm())
+	}
+}
+
+func TestReplaceFileCreatesNew(t *testing.T) {
+	setupWorkspace(t)
+
+	got, err := callTool(t, ReplaceFile, map[string]any{"path": "dir/new.txt", "content": "fresh\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Created dir/new.txt" {
+		t.Errorf("got %q", got)
+	}
+	if content := readFile(t, "dir/new.txt"); content != "fresh\n" {
+		t.Errorf("file has %q", content)
+	}
+}
+
+func TestReplaceFileRejectsDirectory(t *testing.T) {
+	setupWorkspace(t)
+	if err := os.Mkdir("dir", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := callTool(t, ReplaceFile, map[string]any{"path": "dir", "content": "x"})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("got error %v, want ErrInvalidInput", err)
+	}
+}
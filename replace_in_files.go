@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var ReplaceInFilesDefinition = ToolDefinition{
+	Name:        "replace_in_files",
+	Description: "Replace every occurrence of a literal string across all files matching a glob, such as renaming an identifier project-wide. Set 'whole_word' when replacing a short identifier so it doesn't also change longer words containing it. Files ignored by .gitignore, protected files and binary files are skipped. Returns the number of replacements per changed file as JSON.",
+	InputSchema: ReplaceInFilesInputSchema,
+	Function:    ReplaceInFiles,
+	Targets:     ReplaceInFilesTargets,
+	Mutates:     true,
+}
+
+type ReplaceInFilesInput struct {
+	Pattern     string `json:"pattern" jsonschema_description:"The literal text to replace."`
+	Replacement string `json:"replacement" jsonschema_description:"The text to replace it with."`
+	Glob        string `json:"glob,omitempty" jsonschema_description:"Glob of the files to change, e.g. '**/*.go'. Defaults to every file."`
+	WholeWord   bool   `json:"whole_word,omitempty" jsonschema_description:"Only replace matches that are whole words, so 'id' doesn't match inside 'width'."`
+}
+
+var ReplaceInFilesInputSchema = GenerateSchema[ReplaceInFilesInput]()
+
+type fileReplacements struct {
+	File         string `json:"file"`
+	Replacements int    `json:"replacements"`
+}
+
+// plannedReplacement is a file replace_in_files will change along with its new content
+type plannedReplacement struct {
+	file         string
+	resolved     string
+	perm         os.FileMode
+	content      []byte
+	replacements int
+}
+
+func ReplaceInFiles(input json.RawMessage) (string, error) {
+	replaceInFilesInput := ReplaceInFilesInput{}
+	err := json.Unmarshal(input, &replaceInFilesInput)
+	if err != nil {
+		return "", err
+	}
+
+	planned, err := planReplacements(replaceInFilesInput)
+	if err != nil {
+		return "", err
+	}
+
+	changed := []fileReplacements{}
+	for _, replacement := range planned {
+		err = backupFile(replacement.resolved)
+		if err != nil {
+			return "", err
+		}
+		formatted, _ := autoFormat(replacement.resolved, replacement.content)
+		err = writeFileAtomic(replacement.resolved, formatted, replacement.perm)
+		if err != nil {
+			return "", err
+		}
+
+		changed = append(changed, fileReplacements{File: replacement.file, Replacements: replacement.replacements})
+	}
+
+	result, err := json.Marshal(changed)
+	if err != nil {
+		return "", err
+	}
+
+	return string(result), nil
+}
+
+// ReplaceInFilesTargets lists the files a replace_in_files call would change, so each can be checked before any
+// is written
+func ReplaceInFilesTargets(input json.RawMessage) ([]string, error) {
+	replaceInFilesInput := ReplaceInFilesInput{}
+	err := json.Unmarshal(input, &replaceInFilesInput)
+	if err != nil {
+		return nil, err
+	}
+
+	planned, err := planReplacements(replaceInFilesInput)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(planned))
+	for _, replacement := range planned {
+		files = append(files, replacement.file)
+	}
+
+	return files, nil
+}
+
+// planReplacements finds the files the replacement applies to and works out their new content without writing it
+func planReplacements(replaceInFilesInput ReplaceInFilesInput) ([]plannedReplacement, error) {
+	if replaceInFilesInput.Pattern == "" || replaceInFilesInput.Pattern == replaceInFilesInput.Replacement {
+		return nil, fmt.Errorf("invalid input parameters: %w", ErrInvalidInput)
+	}
+
+	pattern := regexp.QuoteMeta(replaceInFilesInput.Pattern)
+	if replaceInFilesInput.WholeWord {
+		pattern = `\b` + pattern + `\b`
+	}
+	re := regexp.MustCompile(pattern)
+
+	glob := strings.TrimPrefix(replaceInFilesInput.Glob, "./")
+	if glob == "" {
+		glob = "**"
+	}
+	files, err := globFiles(glob)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := []plannedReplacement{}
+	for _, file := range files {
+		if protectedPattern(file) != "" {
+			continue
+		}
+
+		resolved, err := resolvePath(file)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, err
+		}
+		if isBinary(content) {
+			continue
+		}
+
+		count := len(re.FindAllIndex(content, -1))
+		if count == 0 {
+			continue
+		}
+
+		planned = append(planned, plannedReplacement{
+			file:         file,
+			resolved:     resolved,
+			perm:         info.Mode().Perm(),
+			content:      re.ReplaceAllLiteral(content, []byte(replaceInFilesInput.Replacement)),
+			replacements: count,
+		})
+	}
+
+	return planned, nil
+}
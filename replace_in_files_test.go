@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReplaceInFilesWholeWord(t *testing.T) {
+	tests := []struct {
+		name      string
+		wholeWord bool
+		want      string
+		result    string
+	}{
+		{"default", false, "x := key\nwkeyth := 2\n", `[{"file":"a.go","replacements":2}]`},
+		{"whole word", true, "x := key\nwidth := 2\n", `[{"file":"a.go","replacements":1}]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "a.go", "x := id\nwidth := 2\n")
+
+			got, err := callTool(t, ReplaceInFiles, map[string]any{"pattern": "id", "replacement": "key", "whole_word": test.wholeWord})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.result {
+				t.Errorf("got %s, want %s", got, test.result)
+			}
+			if content := readFile(t, "a.go"); content != test.want {
+				t.Errorf("a.go has %q, want %q", content, test.want)
+			}
+		})
+	}
+}
+
+func TestReplaceInFilesGlob(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "a.go", "foo\n")
+	writeFile(t, "b.txt", "foo\n")
+
+	got, err := callTool(t, ReplaceInFiles, map[string]any{"pattern": "foo", "replacement": "bar", "glob": "*.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `[{"file":"a.go","replacements":1}]`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if content := readFile(t, "b.txt"); content != "foo\n" {
+		t.Errorf("b.txt outside the glob has %q", content)
+	}
+}
+
+func TestReplaceInFilesInvalidInput(t *testing.T) {
+	setupWorkspace(t)
+
+	for _, input := range []map[string]any{
+		{"pattern": "", "replacement": "x"},
+		{"pattern": "same", "replacement": "same"},
+	} {
+		if _, err := callTool(t, ReplaceInFiles, input); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%v: got %v, want ErrInvalidInput", input, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// replayRecording splits a saved conversation into the messages the user typed and Claude's replies, both in order
+func replayRecording(conversation []anthropic.MessageParam) (prompts []string, replies []anthropic.MessageParam) {
+	for _, message := range conversation {
+		switch {
+		case message.Role == anthropic.MessageParamRoleAssistant:
+			replies = append(replies, message)
+		case !isToolResultMessage(message):
+			var text []string
+			for _, block := range message.Content {
+				if block.OfText != nil {
+					text = append(text, block.OfText.Text)
+				}
+			}
+			prompts = append(prompts, strings.Join(text, "\n"))
+		}
+	}
+
+	return prompts, replies
+}
+
+// replayMiddleware stands in for the API while replaying, answering each request for a message with the next
+// recorded reply. Tool calls in the replies are run for real, which is what makes a replay useful for reproducing
+// tool bugs.
+func replayMiddleware(replies []anthropic.MessageParam) option.Middleware {
+	var mu sync.Mutex
+	next := 0
+
+	return func(req *http.Request, _ option.MiddlewareNext) (*http.Response, error) {
+		if req.URL.Path != "/v1/messages" {
+			return nil, fmt.Errorf("%s isn't available while replaying a session", req.URL.Path)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if next >= len(replies) {
+			return nil, fmt.Errorf("the replayed session has no more recorded replies")
+		}
+		body, err := recordedResponse(replies[next], next)
+		if err != nil {
+			return nil, err
+		}
+		next++
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}, nil
+	}
+}
+
+// recordedResponse turns a saved reply back into the body of an API response
+func recordedResponse(reply anthropic.MessageParam, index int) ([]byte, error) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	var message map[string]any
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+
+	stopReason := anthropic.StopReasonEndTurn
+	for _, block := range reply.Content {
+		if block.OfToolUse != nil {
+			stopReason = anthropic.StopReasonToolUse
+		}
+	}
+	message["id"] = fmt.Sprintf("replay_%d", index)
+	message["type"] = "message"
+	message["model"] = "replay"
+	message["stop_reason"] = stopReason
+	message["usage"] = map[string]int{"input_tokens": 0, "output_tokens": 0}
+
+	return json.Marshal(message)
+}
+
+// Replay sends the recorded prompts again in order, with Claude's side answered from the recording
+func (a *Agent) Replay(ctx context.Context, prompts []string) error {
+	var err error
+	for _, prompt := range prompts {
+		a.requestPrompt()
+		if !a.jsonOutput {
+			fmt.Println(prompt)
+		}
+
+		a.turns++
+		a.emit(outputEvent{Type: "user_message", Text: prompt})
+		a.conversation = append(a.conversation, anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)))
+		if err = a.runTurn(ctx); err != nil {
+			break
+		}
+	}
+
+	return errors.Join(err, a.finish())
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// recordingTool is a tool that writes nothing, only noting the input of each call
+func recordingTool(calls *[]string) ToolDefinition {
+	return ToolDefinition{
+		Name:        "note",
+		Description: "Note something down.",
+		InputSchema: ReadFileInputSchema,
+		Function: func(input json.RawMessage) (string, error) {
+			*calls = append(*calls, string(input))
+			return "noted", nil
+		},
+	}
+}
+
+func TestReplayRecording(t *testing.T) {
+	conversation := append(chat(1), toolExchange("tool_1")...)
+
+	prompts, replies := replayRecording(conversation)
+	if want := []string{"question 1", "read it"}; !slices.Equal(prompts, want) {
+		t.Errorf("prompts are %q, want %q", prompts, want)
+	}
+	if len(replies) != 3 || replies[1].Content[0].OfToolUse == nil {
+		t.Errorf("replies are %+v, want the answer, the tool call and the reply after it", replies)
+	}
+}
+
+func TestReplayFiresSameToolCalls(t *testing.T) {
+	setupWorkspace(t)
+	config.Session = "session.json"
+
+	// Record a session of two turns, each calling the tool once
+	var recorded []string
+	client := fakeAPI(t, func(request map[string]any) string {
+		messages := request["messages"].([]any)
+		last := messages[len(messages)-1].(map[string]any)
+		block := last["content"].([]any)[0].(map[string]any)
+		if block["type"] == "tool_result" {
+			return messageJSON(textBlock("Noted"))
+		}
+		return messageJSON(toolUseBlock("tool_"+block["text"].(string), "note", `{"path":"`+block["text"].(string)+`.txt"}`))
+	})
+	captureStdout(t, func() {
+		if err := newTestAgent(client, []ToolDefinition{recordingTool(&recorded)}, "first", "second").Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	})
+
+	recording, err := loadSession("session.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prompts, replies := replayRecording(recording)
+	replayClient := anthropic.NewClient(option.WithAPIKey("replay"), option.WithMaxRetries(0), option.WithMiddleware(replayMiddleware(replies)))
+
+	config.Session = ""
+	var replayed []string
+	agent := newTestAgent(&replayClient, []ToolDefinition{recordingTool(&replayed)})
+	output := captureStdout(t, func() {
+		if err := agent.Replay(context.Background(), prompts); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if want := []string{`{"path":"first.txt"}`, `{"path":"second.txt"}`}; !slices.Equal(recorded, want) {
+		t.Fatalf("recorded calls %q, want %q", recorded, want)
+	}
+	if !slices.Equal(replayed, recorded) {
+		t.Errorf("replay called the tool with %q, want %q", replayed, recorded)
+	}
+	if strings.Count(output, "Noted") != 2 {
+		t.Errorf("output %q lacks the recorded replies", output)
+	}
+	if len(agent.conversation) != len(recording) {
+		t.Errorf("replayed %d messages, recording has %d", len(agent.conversation), len(recording))
+	}
+
+	// Running out of recorded replies is an error rather than a call to the API
+	if err := agent.Replay(context.Background(), []string{"third"}); err == nil || !strings.Contains(err.Error(), "no more recorded replies") {
+		t.Errorf("got error %v, want the recording exhausted", err)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"time"
+)
+
+const (
+	// readToolRetries is the retry count given to tools that only read and so are safe to repeat
+	readToolRetries = 2
+	toolRetryDelay  = 200 * time.Millisecond
+)
+
+// isRetryable reports whether a tool error might go away on a second attempt. Errors caused by the input itself,
+// or by the state of the workspace, will fail the same way every time.
+func isRetryable(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return false
+	}
+
+	for _, permanent := range []error{
+		ErrInvalidInput,
+		ErrNotFound,
+		ErrOutsideWorkspace,
+		ErrMultipleMatches,
+		ErrFileTooLarge,
+		ErrBinaryFile,
+		ErrCommandsDisabled,
+		ErrTimeout,
+		fs.ErrNotExist,
+	} {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestToolRetriesTransientFailure(t *testing.T) {
+	setupWorkspace(t)
+
+	calls := 0
+	flaky := ToolDefinition{
+		Name:    "flaky",
+		Retries: readToolRetries,
+		Function: func(json.RawMessage) (string, error) {
+			calls++
+			if calls == 1 {
+				return "", errors.New("resource temporarily unavailable")
+			}
+			return "ok", nil
+		},
+	}
+	agent := newTestAgent(nil, []ToolDefinition{flaky})
+
+	content, isError := agent.callToolWithRetries(flaky, json.RawMessage(`{}`)).content()
+	if isError || content != "ok" {
+		t.Errorf("got %q (error %v), want success on the retry", content, isError)
+	}
+	if calls != 2 {
+		t.Errorf("tool called %d times, want 2", calls)
+	}
+}
+
+func TestToolRetriesSkipPermanentFailure(t *testing.T) {
+	setupWorkspace(t)
+
+	calls := 0
+	broken := ToolDefinition{
+		Name:    "broken",
+		Retries: readToolRetries,
+		Function: func(json.RawMessage) (string, error) {
+			calls++
+			return "", fmt.Errorf("bad path: %w", ErrInvalidInput)
+		},
+	}
+	agent := newTestAgent(nil, []ToolDefinition{broken})
+
+	if _, isError := agent.callToolWithRetries(broken, json.RawMessage(`{}`)).content(); !isError {
+		t.Error("got success, want the error")
+	}
+	if calls != 1 {
+		t.Errorf("tool called %d times, want 1", calls)
+	}
+}
+
+func TestToolRetriesGiveUp(t *testing.T) {
+	setupWorkspace(t)
+
+	calls := 0
+	failing := ToolDefinition{
+		Name:    "failing",
+		Retries: readToolRetries,
+		Function: func(json.RawMessage) (string, error) {
+			calls++
+			return "", errors.New("file is locked")
+		},
+	}
+	agent := newTestAgent(nil, []ToolDefinition{failing})
+
+	if _, isError := agent.callToolWithRetries(failing, json.RawMessage(`{}`)).content(); !isError {
+		t.Error("got success, want the error")
+	}
+	if calls != 1+readToolRetries {
+		t.Errorf("tool called %d times, want %d", calls, 1+readToolRetries)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	var syntaxErr error = &json.SyntaxError{}
+	for _, err := range []error{ErrNotFound, fmt.Errorf("wrapped: %w", ErrTimeout), syntaxErr} {
+		if isRetryable(err) {
+			t.Errorf("%v is retryable", err)
+		}
+	}
+	if !isRetryable(errors.New("connection reset")) {
+		t.Error("an unknown error is not retryable")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var RunTestDefinition = ToolDefinition{
+	Name:           "run_test",
+	Description:    "Run a single Go test by name with 'go test -run'. Much faster than building and testing everything when iterating on one test. Accepts subtests as 'TestName/subtest'. Returns whether the test passed together with its output.",
+	InputSchema:    RunTestInputSchema,
+	Function:       RunTest,
+	StreamFunction: RunTestStream,
+	Timeout:        commandToolTimeout,
+}
+
+type RunTestInput struct {
+	Package string `json:"package,omitempty" jsonschema_description:"Relative path of the package directory containing the test. Defaults to the current directory."`
+	Name    string `json:"name" jsonschema_description:"The exact name of the test function, e.g. 'TestParseFlags', or 'TestParseFlags/empty' for a subtest."`
+}
+
+var RunTestInputSchema = GenerateSchema[RunTestInput]()
+
+func RunTest(input json.RawMessage) (string, error) {
+	return RunTestStream(input, nil)
+}
+
+func RunTestStream(input json.RawMessage, stream io.Writer) (string, error) {
+	runTestInput := RunTestInput{}
+	err := json.Unmarshal(input, &runTestInput)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(runTestInput.Name)
+	if name == "" {
+		return "", fmt.Errorf("name must not be empty: %w", ErrInvalidInput)
+	}
+
+	pkg, err := testPackage(runTestInput.Package)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runCommandStream(stream, "go", "test", "-count=1", "-v", "-run", exactTestPattern(name), pkg)
+	if strings.Contains(output, "no tests to run") {
+		return "", fmt.Errorf("no test named %s in %s: %w", name, pkg, ErrNotFound)
+	}
+	if err == nil {
+		return fmt.Sprintf("PASS %s\n%s", name, strings.TrimSpace(output)), nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return "", err
+	}
+
+	return fmt.Sprintf("FAIL %s\n%s", name, strings.TrimSpace(output)), nil
+}
+
+// testPackage turns a package directory into the ./relative form go test expects, rejecting paths outside the workspace
+// or the --exec-dir allowlist
+func testPackage(dir string) (string, error) {
+	if dir == "" {
+		dir = "."
+	}
+
+	resolved, err := resolvePath(dir)
+	if err != nil {
+		return "", err
+	}
+	if !allowedExecDir(resolved) {
+		return "", fmt.Errorf("tests may only run in %s: %w", strings.Join(config.ExecDirs, ", "), ErrExecDirDenied)
+	}
+	base, err := resolvePath(".")
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(base, resolved)
+	if err != nil {
+		return "", err
+	}
+
+	return "./" + filepath.ToSlash(rel), nil
+}
+
+// exactTestPattern anchors each level of a test name so -run matches only that test and not others sharing its prefix
+func exactTestPattern(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(part) + "$"
+	}
+
+	return strings.Join(parts, "/")
+}
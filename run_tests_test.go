@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTestModule creates a module whose calc package has a passing test, a failing one and one sharing a prefix
+func setupTestModule(t *testing.T) {
+	t.Helper()
+
+	setupGoModule(t, map[string]string{
+		"calc/calc.go": "package calc\n\nfunc Add(a, b int) int { return a + b }\n",
+		"calc/calc_test.go": `package calc
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("wrong sum")
+	}
+}
+
+func TestAddNegative(t *testing.T) {
+	t.Fatal("negative numbers are not supported")
+}
+
+func TestBroken(t *testing.T) {
+	if Add(2, 2) != 5 {
+		t.Fatal("2 + 2 is not 5")
+	}
+}
+`,
+	})
+}
+
+func TestRunTest(t *testing.T) {
+	setupTestModule(t)
+
+	tests := []struct {
+		name    string
+		want    string
+		missing string
+	}{
+		{"TestAdd", "PASS TestAdd", "TestAddNegative"},
+		{"TestBroken", "FAIL TestBroken", "TestAdd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := callTool(t, RunTest, map[string]any{"package": "calc", "name": test.name})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(got, test.want) {
+				t.Errorf("got %q, want it to start with %q", got, test.want)
+			}
+			if strings.Contains(got, test.missing) {
+				t.Errorf("output %q ran %s too", got, test.missing)
+			}
+		})
+	}
+}
+
+func TestRunTestRejections(t *testing.T) {
+	setupTestModule(t)
+
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  error
+	}{
+		{"no name", map[string]any{"package": "calc", "name": " "}, ErrInvalidInput},
+		{"unknown test", map[string]any{"package": "calc", "name": "TestMissing"}, ErrNotFound},
+		{"outside the workspace", map[string]any{"package": "..", "name": "TestAdd"}, ErrOutsideWorkspace},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := callTool(t, RunTest, test.input); !errors.Is(err, test.want) {
+				t.Errorf("got error %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRunTestExecDirAllowlist(t *testing.T) {
+	setupTestModule(t)
+	writeFile(t, "other/other_test.go", "package other\n\nimport \"testing\"\n\nfunc TestAdd(t *testing.T) {}\n")
+	config.ExecDirs = []string{"calc"}
+	setWorkDir("calc")
+	root, err := workspaceRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := callTool(t, RunTest, map[string]any{"name": "TestAdd"}); err != nil || !strings.HasPrefix(got, "PASS TestAdd") {
+		t.Errorf("allowed package: got %q, %v", got, err)
+	}
+	for _, pkg := range []string{"../other", filepath.Join(root, "other"), ".."} {
+		if _, err := callTool(t, RunTest, map[string]any{"package": pkg, "name": "TestAdd"}); !errors.Is(err, ErrExecDirDenied) {
+			t.Errorf("package %s: got error %v, want ErrExecDirDenied", pkg, err)
+		}
+	}
+}
+
+func TestExactTestPattern(t *testing.T) {
+	if got, want := exactTestPattern("TestParse/empty.input"), `^TestParse$/^empty\.input$`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
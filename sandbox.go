@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// workDir is the session working directory set by change_directory, relative to the workspace root. --watch
+// reads it in the background, so it is accessed through currentWorkDir and setWorkDir.
+var (
+	workDir   = "."
+	workDirMu sync.Mutex
+)
+
+// currentWorkDir returns a snapshot of the session working directory
+func currentWorkDir() string {
+	workDirMu.Lock()
+	defer workDirMu.Unlock()
+
+	return workDir
+}
+
+// setWorkDir changes the session working directory
+func setWorkDir(dir string) {
+	workDirMu.Lock()
+	defer workDirMu.Unlock()
+
+	workDir = dir
+}
+
+// workspaceRoot returns the absolute path of the sandbox root
+func workspaceRoot() (string, error) {
+	return filepath.Abs(".")
+}
+
+// resolvePath resolves a tool supplied path against the working directory, rejecting any path that escapes the
+// workspace root
+func resolvePath(p string) (string, error) {
+	root, err := workspaceRoot()
+	if err != nil {
+		return "", err
+	}
+
+	full := p
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(root, currentWorkDir(), full)
+	}
+	full = filepath.Clean(full)
+
+	if !withinDir(root, full) {
+		return "", fmt.Errorf("path %q: %w", p, ErrOutsideWorkspace)
+	}
+
+	// Follow symlinks for existing paths so a link cannot be used to reach outside the workspace
+	if resolved, err := filepath.EvalSymlinks(full); err == nil {
+		realRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			return "", err
+		}
+		if !withinDir(realRoot, resolved) {
+			return "", fmt.Errorf("path %q: %w", p, ErrOutsideWorkspace)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	// Paths hidden by .agentignore look missing so nothing reveals they exist
+	info, err := os.Stat(full)
+	if agentIgnored(full, err == nil && info.IsDir()) {
+		return "", fmt.Errorf("%s: %w", p, fs.ErrNotExist)
+	}
+
+	return full, nil
+}
+
+// withinDir reports whether path is dir itself or nested beneath it
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// loadSession reads a conversation saved by saveSession, returning an empty one when the file doesn't exist yet
+func loadSession(filePath string) ([]anthropic.MessageParam, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var conversation []anthropic.MessageParam
+	err = json.Unmarshal(data, &conversation)
+	if err != nil {
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+// saveSession writes the conversation to filePath so a later run can resume it, keeping at most the
+// --history-limit most recent messages
+func saveSession(filePath string, conversation []anthropic.MessageParam) error {
+	data, err := json.Marshal(trimHistory(conversation, config.HistoryLimit))
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filePath, data, 0644)
+}
+
+// trimHistory drops the oldest messages so no more than limit remain. The kept history always starts at a message
+// the user typed, as one starting with tool results or Claude's reply isn't a valid conversation, so it can end up
+// a little shorter than the limit, or longer when a single exchange of tool calls already exceeds it.
+func trimHistory(conversation []anthropic.MessageParam, limit int) []anthropic.MessageParam {
+	if limit <= 0 || len(conversation) <= limit {
+		return conversation
+	}
+
+	cut := len(conversation) - limit
+	latest := -1
+	for i, message := range conversation {
+		if message.Role != anthropic.MessageParamRoleUser || isToolResultMessage(message) {
+			continue
+		}
+		if i >= cut {
+			return conversation[i:]
+		}
+		latest = i
+	}
+	if latest == -1 {
+		return conversation
+	}
+
+	return conversation[latest:]
+}
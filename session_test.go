@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// chat builds a conversation of n exchanges of typed messages and replies, numbered from 1
+func chat(n int) []anthropic.MessageParam {
+	var conversation []anthropic.MessageParam
+	for i := 1; i <= n; i++ {
+		conversation = append(conversation,
+			anthropic.NewUserMessage(anthropic.NewTextBlock(fmt.Sprintf("question %d", i))),
+			anthropic.NewAssistantMessage(anthropic.NewTextBlock(fmt.Sprintf("answer %d", i))))
+	}
+
+	return conversation
+}
+
+// toolExchange is a typed message answered after a tool call
+func toolExchange(id string) []anthropic.MessageParam {
+	return []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock("read it")),
+		anthropic.NewAssistantMessage(anthropic.NewToolUseBlock(id, map[string]any{"path": "a.go"}, "read_file")),
+		anthropic.NewUserMessage(anthropic.NewToolResultBlock(id, "package main", false)),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock("it's a main package")),
+	}
+}
+
+func TestSaveSessionRespectsHistoryLimit(t *testing.T) {
+	setupWorkspace(t)
+	config.HistoryLimit = 6
+
+	if err := saveSession("session.json", chat(20)); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := loadSession("session.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(saved) != 6 {
+		t.Fatalf("saved %d messages, want 6", len(saved))
+	}
+	if got := saved[0].Content[0].OfText.Text; got != "question 18" {
+		t.Errorf("saved history starts at %q, want question 18", got)
+	}
+	if got := saved[5].Content[0].OfText.Text; got != "answer 20" {
+		t.Errorf("saved history ends at %q, want answer 20", got)
+	}
+}
+
+func TestTrimHistory(t *testing.T) {
+	withTools := append(chat(1), toolExchange("tool_1")...)
+	onlyTools := toolExchange("tool_1")[:3]
+	onlyTools = append(onlyTools, toolExchange("tool_2")[1:]...)
+
+	tests := []struct {
+		name         string
+		conversation []anthropic.MessageParam
+		limit        int
+		want         int
+	}{
+		{"no limit", chat(5), 0, 10},
+		{"under the limit", chat(2), 10, 4},
+		{"odd limit starts at a typed message", chat(5), 5, 4},
+		{"skips tool results", withTools, 4, 4},
+		{"keeps a too long tool exchange whole", onlyTools, 2, 6},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := trimHistory(test.conversation, test.limit)
+			if len(got) != test.want {
+				t.Fatalf("kept %d messages, want %d", len(got), test.want)
+			}
+			if first := got[0]; first.Role != anthropic.MessageParamRoleUser || isToolResultMessage(first) {
+				t.Errorf("kept history starts with %+v, want a typed message", first)
+			}
+		})
+	}
+}
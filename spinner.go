@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const spinnerInterval = 100 * time.Millisecond
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// startSpinner animates label on w until the returned stop is called, which erases the line again so whatever
+// is printed next starts from a clean line. Calling stop more than once is harmless.
+func startSpinner(w io.Writer, label string) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+
+		for frame := 0; ; frame++ {
+			fmt.Fprintf(w, "\r%s %s", spinnerFrames[frame%len(spinnerFrames)], label)
+			select {
+			case <-done:
+				fmt.Fprint(w, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+// startProgress shows a spinner while waiting on Claude, when the agent has somewhere to show it
+func (a *Agent) startProgress() (stop func()) {
+	if a.progress == nil {
+		return func() {}
+	}
+
+	return startSpinner(a.progress, a.colorize(ANSI_DIM, "waiting for "+string(a.model)))
+}
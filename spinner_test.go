@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer safe to write from the spinner's goroutine while the test reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSpinner(t *testing.T) {
+	var out lockedBuffer
+	stop := startSpinner(&out, "working")
+	time.Sleep(3 * spinnerInterval)
+	stop()
+	stop()
+
+	got := out.String()
+	if !strings.HasPrefix(got, "\r"+spinnerFrames[0]+" working\r"+spinnerFrames[1]+" working") {
+		t.Errorf("got %q, want successive frames", got)
+	}
+	if !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("got %q, want the line cleared when stopped", got)
+	}
+
+	time.Sleep(2 * spinnerInterval)
+	if out.String() != got {
+		t.Error("the spinner kept drawing after it was stopped")
+	}
+}
+
+func TestProgressAroundInference(t *testing.T) {
+	setupWorkspace(t)
+
+	var progress lockedBuffer
+	var duringRequest string
+	client := fakeAPI(t, func(map[string]any) string {
+		time.Sleep(2 * spinnerInterval)
+		duringRequest = progress.String()
+		return messageJSON(textBlock("Hi there"))
+	})
+	agent := newTestAgent(client, nil)
+	agent.progress = &progress
+
+	output := captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "hello"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if !strings.Contains(duringRequest, "waiting for "+config.Model) {
+		t.Errorf("progress while waiting was %q, want the spinner", duringRequest)
+	}
+	if got := progress.String(); !strings.HasSuffix(got, "\r\033[K") {
+		t.Errorf("progress %q wasn't cleared once the response arrived", got)
+	}
+	if strings.ContainsAny(output, strings.Join(spinnerFrames, "")) {
+		t.Errorf("output %q is mixed up with the spinner", output)
+	}
+}
+
+func TestNoProgressWithoutTerminal(t *testing.T) {
+	setupWorkspace(t)
+
+	if agent := newTestAgent(nil, nil); agent.progress != nil {
+		t.Error("a spinner would be drawn although stdout isn't a terminal")
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxPathSuggestions is how many similar paths are offered when a path doesn't exist
+const maxPathSuggestions = 3
+
+// withPathSuggestions adds the closest existing paths to a not found error so Claude can correct a typo itself.
+// Any other error, or a path with nothing close to it, is returned unchanged.
+func withPathSuggestions(p string, err error) error {
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	suggestions := suggestPaths(p)
+	if len(suggestions) == 0 {
+		return err
+	}
+
+	return fmt.Errorf("%w, did you mean %s?", err, strings.Join(suggestions, " or "))
+}
+
+// suggestPaths returns the workspace files whose relative paths are within a small edit distance of p, closest first
+func suggestPaths(p string) []string {
+	files, err := globFiles("**")
+	if err != nil {
+		return nil
+	}
+
+	target := filepath.ToSlash(filepath.Clean(p))
+	// Allow a transposed pair, or roughly one typo for every four characters in longer paths
+	limit := max(2, len(target)/4)
+
+	type candidate struct {
+		path     string
+		distance int
+	}
+	var candidates []candidate
+	for _, file := range files {
+		if distance := editDistance(target, file); distance <= limit {
+			candidates = append(candidates, candidate{file, distance})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return a.distance - b.distance
+	})
+
+	suggestions := []string{}
+	for _, c := range candidates[:min(len(candidates), maxPathSuggestions)] {
+		suggestions = append(suggestions, c.path)
+	}
+
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
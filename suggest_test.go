@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"main.go", "main.go", 0},
+		{"mian.go", "main.go", 2},
+		{"main.g", "main.go", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestSuggestPaths(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "main.go", "package main\n")
+	writeFile(t, "main_test.go", "package main\n")
+	writeFile(t, "internal/config.go", "package internal\n")
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"mian.go", []string{"main.go"}},
+		{"./internal/confg.go", []string{"internal/config.go"}},
+		{"completely/unrelated/name.txt", []string{}},
+	}
+
+	for _, test := range tests {
+		if got := suggestPaths(test.path); !slices.Equal(got, test.want) {
+			t.Errorf("suggestPaths(%q) = %v, want %v", test.path, got, test.want)
+		}
+	}
+}
+
+func TestReadFileSuggestsPaths(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "greeting.txt", "hello")
+
+	_, err := callTool(t, ReadFile, map[string]any{"path": "greting.txt"})
+	if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), "did you mean greeting.txt?") {
+		t.Errorf("near miss: got %v, want a suggestion of greeting.txt", err)
+	}
+
+	_, err = callTool(t, EditFile, map[string]any{"path": "nothing/like/it.md", "old_str": "a", "new_str": "b"})
+	if !errors.Is(err, fs.ErrNotExist) || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("wildly wrong path: got %v, want a plain not found error", err)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultLineCount is how many lines tail_file and similar tools return when no count is given
+const defaultLineCount = 50
+
+var TailFileDefinition = ToolDefinition{
+	Name:        "tail_file",
+	Description: "Read the last lines of a file without loading the whole file. Use this for logs and other large files where the end is what matters.",
+	InputSchema: TailFileInputSchema,
+	Function:    TailFile,
+	Retries:     readToolRetries,
+}
+
+type TailFileInput struct {
+	Path  string `json:"path" jsonschema_description:"The relative path of the file to read."`
+	Lines int    `json:"lines,omitempty" jsonschema_description:"The number of trailing lines to return. Defaults to 50."`
+}
+
+var TailFileInputSchema = GenerateSchema[TailFileInput]()
+
+func TailFile(input json.RawMessage) (string, error) {
+	tailFileInput := TailFileInput{}
+	err := json.Unmarshal(input, &tailFileInput)
+	if err != nil {
+		return "", err
+	}
+
+	lines := tailFileInput.Lines
+	if lines == 0 {
+		lines = defaultLineCount
+	}
+	if lines < 0 {
+		return "", fmt.Errorf("lines must be positive: %w", ErrInvalidInput)
+	}
+
+	resolved, err := resolvePath(tailFileInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkRegularFile(resolved)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(resolved)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	return tailLines(file, info.Size(), lines)
+}
+
+// tailLines reads backwards from the end of file in chunks until it has the last n lines. Reading stops at the file
+// size limit, so a file of very long lines, or none, isn't loaded whole.
+func tailLines(file *os.File, size int64, n int) (string, error) {
+	const chunkSize = 4096
+
+	var tail []byte
+	offset := size
+	truncated := false
+	// One newline more than n is needed because the file usually ends with one
+	for offset > 0 && bytes.Count(tail, []byte("\n")) <= n {
+		if config.MaxFileSize > 0 && int64(len(tail)) >= config.MaxFileSize {
+			truncated = true
+			break
+		}
+
+		readSize := min(chunkSize, offset)
+		offset -= readSize
+
+		chunk := make([]byte, readSize)
+		if _, err := file.ReadAt(chunk, offset); err != nil {
+			return "", err
+		}
+		tail = append(chunk, tail...)
+	}
+
+	if isBinary(tail) {
+		return "", fmt.Errorf("file %s appears to be binary: %w", file.Name(), ErrBinaryFile)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(tail), "\n"), "\n")
+	// Stopping at the limit leaves the first line read as only the end of a longer one
+	partial := truncated && len(lines) <= n
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	result := strings.Join(lines, "\n")
+
+	if config.MaxFileSize > 0 && int64(len(result)) > config.MaxFileSize {
+		result = result[int64(len(result))-config.MaxFileSize:]
+		partial = true
+	}
+	if partial {
+		return fmt.Sprintf("[truncated to the last %d bytes]\n%s", config.MaxFileSize, result), nil
+	}
+
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns "line 1\n" through "line n\n"
+func numberedLines(n int) string {
+	var lines strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&lines, "line %d\n", i)
+	}
+	return lines.String()
+}
+
+func TestTailFile(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int
+		lines int
+		want  string
+	}{
+		// Spans several of the chunks the file is read back in
+		{name: "larger than tail", total: 2000, lines: 3, want: "line 1998\nline 1999\nline 2000"},
+		{name: "smaller than tail", total: 2, lines: 10, want: "line 1\nline 2"},
+		{name: "default count", total: 60, want: strings.TrimSuffix(strings.TrimPrefix(numberedLines(60), numberedLines(10)), "\n")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setupWorkspace(t)
+			writeFile(t, "log.txt", numberedLines(test.total))
+
+			input := map[string]any{"path": "log.txt"}
+			if test.lines != 0 {
+				input["lines"] = test.lines
+			}
+			got, err := callTool(t, TailFile, input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTailFileNegativeLines(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "log.txt", "one\n")
+
+	_, err := callTool(t, TailFile, map[string]any{"path": "log.txt", "lines": -1})
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("got error %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestTailFileSizeLimit(t *testing.T) {
+	setupWorkspace(t)
+	config.MaxFileSize = 1000
+	long := strings.Repeat("x", 100000) + "END"
+	writeFile(t, "one-line.txt", long)
+	writeFile(t, "long-lines.txt", "short\n"+strings.Repeat("y", 3000)+"\nlast\n")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"one-line.txt", "[truncated to the last 1000 bytes]\n" + long[len(long)-1000:]},
+		{"long-lines.txt", "[truncated to the last 1000 bytes]\n" + strings.Repeat("y", 995) + "\nlast"},
+	}
+
+	for _, test := range tests {
+		got, err := callTool(t, TailFile, map[string]any{"path": test.path, "lines": 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %d bytes starting %.60q, want %d bytes", test.path, len(got), got, len(test.want))
+		}
+	}
+}
+
+func TestTailFileRefusesBinaryAndDirectories(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "image.bin", "header\n\x00\x01\x02\n")
+	writeFile(t, "logs/app.log", "line\n")
+
+	if _, err := callTool(t, TailFile, map[string]any{"path": "image.bin"}); !errors.Is(err, ErrBinaryFile) {
+		t.Errorf("binary file: got error %v, want ErrBinaryFile", err)
+	}
+	if _, err := callTool(t, TailFile, map[string]any{"path": "logs"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("directory: got error %v, want ErrInvalidInput", err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateDir is where --template looks up templates given by name, as <name>.tmpl
+const templateDir = ".agent/templates"
+
+// templateFile finds the file for a --template, which is either the path of a file or the name of one in templateDir
+func templateFile(name string) string {
+	if info, err := os.Stat(name); err == nil && info.Mode().IsRegular() {
+		return name
+	}
+
+	return filepath.Join(templateDir, name+".tmpl")
+}
+
+// renderTemplate fills in a text/template prompt with the --var values, failing on any variable that wasn't given
+// rather than sending a prompt with a hole in it. Templates can pull in workspace files with {{file "path"}}.
+func renderTemplate(name string, vars map[string]string) (string, error) {
+	path := templateFile(name)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("template %q not found, expected a file or %s", name, path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	funcs := template.FuncMap{
+		"file": func(path string) (string, error) {
+			return readSandboxedTextFile(path, false)
+		},
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, vars); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// varFlag collects name=value pairs for --var into target
+func varFlag(target *map[string]string) func(string) error {
+	return func(value string) error {
+		name, val, ok := strings.Cut(value, "=")
+		if !ok || name == "" {
+			return fmt.Errorf("expected name=value, got %q", value)
+		}
+
+		if *target == nil {
+			*target = map[string]string{}
+		}
+		(*target)[name] = val
+		return nil
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "review.tmpl", "Review {{.files}} for {{.focus}}.\n")
+	writeFile(t, filepath.Join(templateDir, "summary.tmpl"), "Summarise:\n{{file .path}}\n")
+	writeFile(t, "notes.txt", "remember the milk")
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{"review.tmpl", map[string]string{"files": "main.go", "focus": "error handling"}, "Review main.go for error handling."},
+		{"summary", map[string]string{"path": "notes.txt"}, "Summarise:\nremember the milk"},
+	}
+
+	for _, test := range tests {
+		got, err := renderTemplate(test.name, test.vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "review.tmpl", "Review {{.files}}")
+	writeFile(t, "secret.tmpl", `{{file "../outside.txt"}}`)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"review.tmpl", `map has no entry for key "files"`},
+		{"missing", `template "missing" not found, expected a file or .agent/templates/missing.tmpl`},
+		{"secret.tmpl", "outside"},
+	}
+
+	for _, test := range tests {
+		if _, err := renderTemplate(test.name, nil); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestVarFlag(t *testing.T) {
+	cfg, err := ParseFlags([]string{"--template", "review", "--var", "files=a.go,b.go", "--var", "focus=x=y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TemplateVars["files"] != "a.go,b.go" || cfg.TemplateVars["focus"] != "x=y" {
+		t.Errorf("vars are %v", cfg.TemplateVars)
+	}
+
+	if _, err := ParseFlags([]string{"--var", "novalue"}); err == nil {
+		t.Error("a --var without = was accepted")
+	}
+}
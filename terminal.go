@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// isTerminal reports whether f is attached to a terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the number of columns of the terminal on stdout, or 0 when it can't be told
+func terminalWidth() int {
+	if !isTerminal(os.Stdout) {
+		return 0
+	}
+
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	// stty reports the size of the terminal on its stdin as "rows columns"
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0
+	}
+	columns, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0
+	}
+
+	return columns
+}
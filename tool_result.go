@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ToolStatus says whether a tool call did what was asked
+type ToolStatus string
+
+const (
+	ToolSuccess ToolStatus = "success"
+	ToolError   ToolStatus = "error"
+)
+
+// ToolResult is the outcome of a tool call: a status, a message for Claude and optionally structured data,
+// which is sent as JSON after the message
+type ToolResult struct {
+	Status  ToolStatus
+	Message string
+	Data    any
+}
+
+func toolSuccess(message string) ToolResult {
+	return ToolResult{Status: ToolSuccess, Message: message}
+}
+
+func toolError(message string) ToolResult {
+	return ToolResult{Status: ToolError, Message: message}
+}
+
+// content renders the result as the text of a tool result block, reporting whether it is an error
+func (r ToolResult) content() (string, bool) {
+	isError := r.Status == ToolError
+	if r.Data == nil {
+		return r.Message, isError
+	}
+
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return fmt.Sprintf("failed to encode the tool result: %v", err), true
+	}
+	if r.Message == "" {
+		return string(data), isError
+	}
+
+	return r.Message + "\n" + string(data), isError
+}
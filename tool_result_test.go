@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestToolResultContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    ToolResult
+		want      string
+		wantError bool
+	}{
+		{"message", toolSuccess("done"), "done", false},
+		{"error", toolError("failed"), "failed", true},
+		{"data only", ToolResult{Status: ToolSuccess, Data: map[string]int{"count": 2}}, `{"count":2}`, false},
+		{"message and data", ToolResult{Status: ToolError, Message: "2 errors", Data: []string{"a", "b"}}, "2 errors\n[\"a\",\"b\"]", true},
+		{"unencodable data", ToolResult{Status: ToolSuccess, Data: func() {}}, "failed to encode the tool result: json: unsupported type: func()", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, isError := test.result.content()
+			if got != test.want || isError != test.wantError {
+				t.Errorf("got %q, %v, want %q, %v", got, isError, test.want, test.wantError)
+			}
+		})
+	}
+}
+
+type structuredInput struct {
+	Fail bool `json:"fail,omitempty"`
+}
+
+func TestExecuteToolEnvelopes(t *testing.T) {
+	setupWorkspace(t)
+	config.Quiet = true
+
+	structured := ToolDefinition{
+		Name:        "structured",
+		InputSchema: GenerateSchema[structuredInput](),
+		ResultFunction: func(input json.RawMessage) (ToolResult, error) {
+			var args structuredInput
+			if err := json.Unmarshal(input, &args); err != nil {
+				return ToolResult{}, err
+			}
+			if args.Fail {
+				return ToolResult{Status: ToolError, Message: "1 problem found", Data: []string{"main.go:3"}}, nil
+			}
+			return ToolResult{Status: ToolSuccess, Message: "all good", Data: map[string]int{"files": 2}}, nil
+		},
+	}
+	plain := ToolDefinition{
+		Name:     "plain",
+		Function: func(json.RawMessage) (string, error) { return "", errors.New("broken") },
+	}
+	agent := newTestAgent(nil, []ToolDefinition{structured, plain})
+
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantError bool
+	}{
+		{"structured", `{}`, "all good\n{\"files\":2}", false},
+		{"structured", `{"fail":true}`, "1 problem found\n[\"main.go:3\"]", true},
+		{"plain", `{}`, "broken", true},
+	}
+
+	for _, test := range tests {
+		block := agent.executeTool("tool_1", test.name, json.RawMessage(test.input)).OfToolResult
+		got := block.Content[0].OfText.Text
+		if got != test.want || block.IsError.Value != test.wantError {
+			t.Errorf("%s %s: got %q, error %v, want %q, error %v", test.name, test.input, got, block.IsError.Value, test.want, test.wantError)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// writeTranscript saves the conversation to filePath as a readable Markdown document
+func writeTranscript(filePath string, conversation []anthropic.MessageParam, usage usageTotals) error {
+	return os.WriteFile(filePath, []byte(renderTranscript(conversation, usage)), 0644)
+}
+
+// renderTranscript formats the conversation as Markdown with a section per message and a usage summary
+func renderTranscript(conversation []anthropic.MessageParam, usage usageTotals) string {
+	var out strings.Builder
+	out.WriteString("# Conversation transcript\n")
+
+	for _, message := range conversation {
+		if message.Role == anthropic.MessageParamRoleAssistant {
+			fmt.Fprintf(&out, "\n## %s\n", config.AssistantLabel)
+		} else if isToolResultMessage(message) {
+			out.WriteString("\n## Tool results\n")
+		} else {
+			fmt.Fprintf(&out, "\n## %s\n", config.UserLabel)
+		}
+
+		for _, block := range message.Content {
+			switch {
+			case block.OfText != nil:
+				fmt.Fprintf(&out, "\n%s\n", block.OfText.Text)
+			case block.OfToolUse != nil:
+				input, _ := json.MarshalIndent(block.OfToolUse.Input, "", "  ")
+				fmt.Fprintf(&out, "\n**Tool call:** `%s`\n\n%s", block.OfToolUse.Name, fencedBlock("json", redactSecrets(string(input))))
+			case block.OfToolResult != nil:
+				status := "Tool result"
+				if block.OfToolResult.IsError.Or(false) {
+					status = "Tool error"
+				}
+				fmt.Fprintf(&out, "\n**%s:**\n\n%s", status, fencedBlock("", redactSecrets(toolResultText(block.OfToolResult))))
+			}
+		}
+	}
+
+	out.WriteString("\n## Usage\n\n")
+	fmt.Fprintf(&out, "- Input tokens: %d\n", usage.InputTokens)
+	fmt.Fprintf(&out, "- Output tokens: %d\n", usage.OutputTokens)
+	if usage.ThinkingTokens > 0 {
+		fmt.Fprintf(&out, "- Thinking tokens (estimated, part of output): %d\n", usage.ThinkingTokens)
+	}
+
+	return out.String()
+}
+
+// isToolResultMessage reports whether a user role message only carries tool results rather than user input
+func isToolResultMessage(message anthropic.MessageParam) bool {
+	for _, block := range message.Content {
+		if block.OfToolResult == nil {
+			return false
+		}
+	}
+
+	return len(message.Content) > 0
+}
+
+// toolResultText joins the text content of a tool result block
+func toolResultText(result *anthropic.ToolResultBlockParam) string {
+	var parts []string
+	for _, content := range result.Content {
+		if content.OfText != nil {
+			parts = append(parts, content.OfText.Text)
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+// fencedBlock wraps text in a Markdown code fence long enough not to be closed by any backticks inside it
+func fencedBlock(language, text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+
+	return fmt.Sprintf("%s%s\n%s\n%s\n", fence, language, strings.TrimRight(text, "\n"), fence)
+}
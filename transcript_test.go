@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTranscript(t *testing.T) {
+	setupWorkspace(t)
+	config.Transcript = "transcript.md"
+	writeFile(t, "greeting.txt", "hello")
+
+	client := fakeAPI(t, toolThenText("read_file", `{"path":"greeting.txt"}`, "It says hello", nil))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+	captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "Read greeting.txt"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	want := "# Conversation transcript\n" +
+		"\n## You\n\nRead greeting.txt\n" +
+		"\n## Claude\n\n**Tool call:** `read_file`\n\n```json\n{\n  \"path\": \"greeting.txt\"\n}\n```\n" +
+		"\n## Tool results\n\n**Tool result:**\n\n```\nhello\n```\n" +
+		"\n## Claude\n\nIt says hello\n" +
+		"\n## Usage\n\n- Input tokens: 20\n- Output tokens: 10\n"
+	if got := readFile(t, "transcript.md"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFencedBlockOutgrowsBackticks(t *testing.T) {
+	got := fencedBlock("", "code with ``` inside")
+	if !strings.HasPrefix(got, "````\n") || !strings.HasSuffix(got, "\n````\n") {
+		t.Errorf("got %q, want a four backtick fence", got)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// validateToolInput checks input against a tool's generated JSON schema, so malformed input is reported precisely
+// instead of surfacing as a confusing failure inside the tool. Only the schema keywords the generator emits are
+// supported: type, properties, required, items, enum, minimum and oneOf.
+func validateToolInput(inputSchema anthropic.ToolInputSchemaParam, input json.RawMessage) error {
+	encoded, err := json.Marshal(inputSchema)
+	if err != nil {
+		return err
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(encoded, &schema); err != nil {
+		return err
+	}
+
+	// Input from a response cut off mid call is truncated, which must never reach the tool as if it were whole
+	var value any
+	if err := json.Unmarshal(input, &value); err != nil {
+		return fmt.Errorf("input is not valid JSON (%v), the response may have been cut off, send the call again: %w", err, ErrInvalidJSON)
+	}
+
+	problems := validateValue(schema, value, "input")
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %w", strings.Join(problems, "; "), ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// validateValue returns a description of each way value fails to match schema, naming where with path
+func validateValue(schema map[string]any, value any, path string) []string {
+	if alternatives, ok := schema["oneOf"].([]any); ok {
+		for _, alternative := range alternatives {
+			if alt, ok := alternative.(map[string]any); ok && len(validateValue(alt, value, path)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s does not match any of the allowed forms", path)}
+	}
+
+	if expected, ok := schema["type"].(string); ok && !matchesType(expected, value) {
+		return []string{fmt.Sprintf("%s must be of type %s, got %s", path, expected, jsonTypeName(value))}
+	}
+
+	var problems []string
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		problems = append(problems, fmt.Sprintf("%s must be one of %v", path, enum))
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if number, ok := value.(float64); ok && number < minimum {
+			problems = append(problems, fmt.Sprintf("%s must be at least %g", path, minimum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("missing required property %q", name))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			property, ok := properties[name].(map[string]any)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("unknown property %q", name))
+				continue
+			}
+			problems = append(problems, validateValue(property, v[name], name)...)
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				problems = append(problems, validateValue(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	return problems
+}
+
+// matchesType reports whether a decoded JSON value is of the named JSON schema type
+func matchesType(expected string, value any) bool {
+	switch expected {
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "null":
+		return value == nil
+	default:
+		return jsonTypeName(value) == expected
+	}
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
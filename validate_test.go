@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestValidateToolInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema anthropic.ToolInputSchemaParam
+		input  string
+		want   string
+	}{
+		{name: "missing path", schema: ReadFileInputSchema, input: `{}`, want: `missing required property "path"`},
+		{name: "wrong type", schema: ReadFileInputSchema, input: `{"path":3}`, want: "path must be of type string, got number"},
+		{name: "unknown property", schema: ReadFileInputSchema, input: `{"path":"a","mode":"x"}`, want: `unknown property "mode"`},
+		{name: "array items", schema: ReadFilesInputSchema, input: `{"paths":["a",1]}`, want: "paths[1] must be of type string, got number"},
+		{name: "integer", schema: DeleteLinesInputSchema, input: `{"path":"a","start_line":1.5,"end_line":2}`, want: "start_line must be of type integer, got number"},
+		{name: "one of", schema: EditFileInputSchema, input: `{"path":"a","old_str":"x","new_str":"y","occurrence":"some"}`, want: "occurrence does not match any of the allowed forms"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateToolInput(test.schema, json.RawMessage(test.input))
+			if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateToolInputAccepts(t *testing.T) {
+	for _, input := range []string{
+		`{"path":"a.txt","new_str":"y"}`,
+		`{"path":"a.txt","old_str":"x","new_str":"y","occurrence":"all"}`,
+		`{"path":"a.txt","old_str":"x","new_str":"y","occurrence":2}`,
+	} {
+		if err := validateToolInput(EditFileInputSchema, json.RawMessage(input)); err != nil {
+			t.Errorf("%s: %v", input, err)
+		}
+	}
+}
+
+func TestTruncatedToolInput(t *testing.T) {
+	setupWorkspace(t)
+	agent := newTestAgent(nil, []ToolDefinition{CreateFileDefinition})
+
+	for _, input := range []string{
+		`{"path":"notes.txt","content":"first line\nsec`,
+		`{"path":"notes.txt"`,
+		``,
+	} {
+		var result ToolResult
+		captureStdout(t, func() {
+			result = agent.runTool("tool_1", "create_file", json.RawMessage(input))
+		})
+
+		if result.Status != ToolError || !strings.HasPrefix(result.Message, "create_file was not run: input is not valid JSON") {
+			t.Errorf("input %q: got %s %q, want a not valid JSON error", input, result.Status, result.Message)
+		}
+		if !strings.Contains(result.Message, "send the call again") {
+			t.Errorf("input %q: error %q doesn't ask for the call again", input, result.Message)
+		}
+	}
+	if _, err := os.Stat("notes.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("the tool ran with truncated input: %v", err)
+	}
+
+	if err := validateToolInput(ReadFileInputSchema, json.RawMessage(`{"path":"a`)); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("got error %v, want ErrInvalidJSON", err)
+	}
+}
+
+func TestSchemaErrorReachesClaude(t *testing.T) {
+	setupWorkspace(t)
+
+	var toolResult string
+	client := fakeAPI(t, toolThenText("read_file", `{"file":"a.txt"}`, "ok", &toolResult))
+	agent := newTestAgent(client, []ToolDefinition{ReadFileDefinition})
+	captureStdout(t, func() {
+		if err := agent.RunOnce(context.Background(), "read"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if !strings.Contains(toolResult, `input does not match the read_file schema: missing required property \"path\"; unknown property \"file\"`) {
+		t.Errorf("tool result sent to Claude = %s", toolResult)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+var VetDefinition = ToolDefinition{
+	Name:           "vet",
+	Description:    "Run static analysis over all Go packages in the working directory with 'go vet ./...'. Use this to catch likely bugs such as bad format strings that still compile. Returns either a clean result or the list of issues found.",
+	InputSchema:    VetInputSchema,
+	Function:       Vet,
+	StreamFunction: VetStream,
+	Timeout:        commandToolTimeout,
+}
+
+type VetInput struct{}
+
+var VetInputSchema = GenerateSchema[VetInput]()
+
+func Vet(input json.RawMessage) (string, error) {
+	return VetStream(input, nil)
+}
+
+func VetStream(input json.RawMessage, stream io.Writer) (string, error) {
+	vetInput := VetInput{}
+	err := json.Unmarshal(input, &vetInput)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := runCommandStream(stream, "go", "vet", "./...")
+	if err == nil {
+		return "go vet is clean: no issues found", nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return "", err
+	}
+
+	issues := parseDiagnostics(output)
+	if len(issues) == 0 {
+		return "", fmt.Errorf("go vet failed: %s", strings.TrimSpace(output))
+	}
+
+	return fmt.Sprintf("go vet found %d issues:\n%s", len(issues), strings.Join(issues, "\n")), nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVetClean(t *testing.T) {
+	setupGoModule(t, map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	got, err := callTool(t, Vet, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "clean") {
+		t.Errorf("got %q, want a clean result", got)
+	}
+}
+
+func TestVetReportsPrintfMistake(t *testing.T) {
+	setupGoModule(t, map[string]string{"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Printf(\"%d\\n\", \"text\")\n}\n"})
+
+	got, err := callTool(t, Vet, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "go vet found 1 issues:") || !strings.Contains(got, "main.go:6:") {
+		t.Errorf("got %q, want the Printf issue on main.go line 6", got)
+	}
+}
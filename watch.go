@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the workspace must stay unchanged before --watch runs its command, so a burst of edits
+// runs it once
+const watchDebounce = 300 * time.Millisecond
+
+// workspaceWatcher notifies of changes to files under root, skipping what glob skips so ignored build output
+// doesn't retrigger the command
+type workspaceWatcher struct {
+	watcher *fsnotify.Watcher
+	root    string
+	ignore  *gitignore
+}
+
+// newWorkspaceWatcher watches root and every directory beneath it that isn't skipped
+func newWorkspaceWatcher(root string) (*workspaceWatcher, error) {
+	ignore, err := loadGitignore()
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &workspaceWatcher{watcher: watcher, root: root, ignore: ignore}
+	if err := w.add(root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Close stops watching
+func (w *workspaceWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// add watches dir and the directories beneath it, as fsnotify only reports changes to a directory's own entries
+func (w *workspaceWatcher) add(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// A directory removed while it is walked has nothing left to watch
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if w.skipped(p, true) {
+			return filepath.SkipDir
+		}
+
+		return w.watcher.Add(p)
+	})
+}
+
+// skipped reports whether glob would leave out a path, which changes to are ignored
+func (w *workspaceWatcher) skipped(p string, isDir bool) bool {
+	relPath, err := filepath.Rel(w.root, p)
+	if err != nil || relPath == "." {
+		return false
+	}
+	name := filepath.Base(p)
+
+	return isDir && (name == ".git" || name == trashDir) || w.ignore.Match(p, isDir) || agentIgnored(p, isDir)
+}
+
+// run waits for changes until ctx is done, calling fn once files have changed and then stayed unchanged for debounce
+func (w *workspaceWatcher) run(ctx context.Context, debounce time.Duration, fn func()) error {
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			// Permission and timestamp changes alone leave the content as it was
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			info, err := os.Stat(event.Name)
+			isDir := err == nil && info.IsDir()
+			if w.skipped(event.Name, isDir) {
+				continue
+			}
+			if isDir && event.Has(fsnotify.Create) {
+				if err := w.add(event.Name); err != nil {
+					return err
+				}
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			fn()
+		}
+	}
+}
+
+// watch re-runs command whenever the workspace changes, printing its output alongside the conversation
+func (a *Agent) watch(ctx context.Context, command string) {
+	err := a.watchWorkspace(ctx, command)
+	if err != nil {
+		a.commandPrompt(fmt.Sprintf("--watch stopped: %v", err))
+	}
+}
+
+// watchWorkspace watches the whole workspace for changes, running command after each burst of them
+func (a *Agent) watchWorkspace(ctx context.Context, command string) error {
+	root, err := workspaceRoot()
+	if err != nil {
+		return err
+	}
+	watcher, err := newWorkspaceWatcher(root)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	return watcher.run(ctx, watchDebounce, func() {
+		a.watchPrompt(command, runWatchCommand(ctx, command))
+	})
+}
+
+// runWatchCommand runs command through the shell in the workspace, describing how it went
+func runWatchCommand(ctx context.Context, command string) string {
+	ctx, cancel := context.WithTimeout(ctx, config.CommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if root, err := workspaceRoot(); err == nil {
+		cmd.Dir = filepath.Join(root, currentWorkDir())
+	}
+
+	output, err := cmd.CombinedOutput()
+	result := strings.TrimSpace(string(output))
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Sprintf("%s\ntimed out after %s", result, config.CommandTimeout)
+	case errors.As(err, &exitErr):
+		return fmt.Sprintf("%s\nexit status %d", result, exitErr.ExitCode())
+	case err != nil:
+		return err.Error()
+	}
+
+	return result
+}
+
+// Watch prompt for the output of a --watch command
+func (a *Agent) watchPrompt(command, output string) {
+	if a.jsonOutput {
+		a.emit(outputEvent{Type: "watch", Name: command, Text: output})
+		return
+	}
+	fmt.Printf("\n%s\n%s\n", a.colorize(ANSI_DIM, "[watch] "+command), output)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDebounce is short so the tests are quick but long enough to cover a burst of writes
+const testDebounce = 200 * time.Millisecond
+
+// startWatcher watches the workspace until the test ends, returning a channel that receives each run
+func startWatcher(t *testing.T) <-chan struct{} {
+	t.Helper()
+
+	root, err := workspaceRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := newWorkspaceWatcher(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- watcher.run(ctx, testDebounce, func() { runs <- struct{}{} })
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("watcher failed: %v", err)
+		}
+		watcher.Close()
+	})
+
+	return runs
+}
+
+// countRuns waits for the runs that happen within d
+func countRuns(runs <-chan struct{}, d time.Duration) int {
+	count := 0
+	timeout := time.After(d)
+	for {
+		select {
+		case <-runs:
+			count++
+		case <-timeout:
+			return count
+		}
+	}
+}
+
+func TestWatchRunsOnceAfterDebounce(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, "main.go", "package main\n")
+	runs := startWatcher(t)
+
+	start := time.Now()
+	for _, content := range []string{"package main\n\n", "package main\n\nfunc main() {}\n"} {
+		writeFile(t, "main.go", content)
+		time.Sleep(testDebounce / 4)
+	}
+	writeFile(t, "util.go", "package main\n")
+
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command never ran")
+	}
+	if elapsed := time.Since(start); elapsed < testDebounce {
+		t.Errorf("ran after %s, before the debounce", elapsed)
+	}
+	if extra := countRuns(runs, 3*testDebounce); extra != 0 {
+		t.Errorf("ran %d more times for a single burst of changes", extra)
+	}
+}
+
+func TestWatchSkipsIgnoredFiles(t *testing.T) {
+	setupWorkspace(t)
+	writeFile(t, ".gitignore", "build/\n*.log\n")
+	writeFile(t, ".git/HEAD", "ref: refs/heads/main\n")
+	writeFile(t, "build/.keep", "")
+	runs := startWatcher(t)
+
+	writeFile(t, "build/app", "binary")
+	writeFile(t, "test.log", "output")
+	writeFile(t, ".git/index", "index")
+	if err := os.Chmod(".gitignore", 0600); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRuns(runs, 3*testDebounce); n != 0 {
+		t.Errorf("ran %d times for ignored changes", n)
+	}
+
+	// Directories created after the watch starts are watched too
+	if err := os.Mkdir("pkg", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRuns(runs, 3*testDebounce); n != 1 {
+		t.Fatalf("ran %d times for a new directory, want 1", n)
+	}
+	writeFile(t, "pkg/lib.go", "package pkg\n")
+	if n := countRuns(runs, 3*testDebounce); n != 1 {
+		t.Errorf("ran %d times for a file in the new directory, want 1", n)
+	}
+}
+
+func TestRunWatchCommand(t *testing.T) {
+	setupWorkspace(t)
+	config.CommandTimeout = time.Minute
+	writeFile(t, "sub/file.txt", "x")
+	setWorkDir("sub")
+
+	if got := runWatchCommand(context.Background(), "pwd"); !strings.HasSuffix(got, "/sub") {
+		t.Errorf("ran in %q, want the working directory", got)
+	}
+	if got, want := runWatchCommand(context.Background(), "echo failing; exit 3"), "failing\nexit status 3"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	config.CommandTimeout = 50 * time.Millisecond
+	if got := runWatchCommand(context.Background(), "exec sleep 5"); !strings.Contains(got, "timed out after 50ms") {
+		t.Errorf("got %q, want a timeout", got)
+	}
+}
+
+func TestRunWatchCommandDuringChangeDirectory(t *testing.T) {
+	setupWorkspace(t)
+	config.CommandTimeout = time.Minute
+	writeFile(t, "a/file.txt", "x")
+	writeFile(t, "b/file.txt", "x")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, dir := range []string{"a", "..", "b", ".."} {
+			if _, err := callTool(t, ChangeDirectory, map[string]any{"path": dir}); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for range 4 {
+		runWatchCommand(context.Background(), "true")
+	}
+	<-done
+}
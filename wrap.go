@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// wrapText word wraps prose to width columns, with the first line offset columns shorter to leave room for a
+// label printed before it. Fenced code blocks are left as they are, as are words too long to fit on a line.
+// Wrapped lines keep the indentation of the line they came from so lists stay readable.
+func wrapText(text string, width, offset int) string {
+	if width <= 0 {
+		return text
+	}
+
+	var out []string
+	inFence := false
+	for i, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			out = append(out, line)
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		available := width
+		if i == 0 {
+			available -= offset
+		}
+		out = append(out, wrapLine(line, available, width)...)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// wrapLine splits a single line into lines no longer than width, the first being limited to firstWidth
+func wrapLine(line string, firstWidth, width int) []string {
+	trimmed := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(trimmed)]
+	words := strings.Fields(trimmed)
+	if len(words) == 0 {
+		return []string{line}
+	}
+
+	var lines []string
+	current := indent + words[0]
+	limit := firstWidth
+	for _, word := range words[1:] {
+		if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > limit {
+			lines = append(lines, current)
+			current = indent + word
+			limit = width
+			continue
+		}
+		current += " " + word
+	}
+
+	return append(lines, current)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		width  int
+		offset int
+		want   string
+	}{
+		{"prose", "the quick brown fox jumps over the lazy dog", 15, 0, "the quick brown\nfox jumps over\nthe lazy dog"},
+		{"offset", "the quick brown fox", 10, 4, "the\nquick\nbrown fox"},
+		{"indent", "  - one two three four", 12, 0, "  - one two\n  three four"},
+		{"long word", "see https://example.com/a/very/long/path now", 10, 0, "see\nhttps://example.com/a/very/long/path\nnow"},
+		{"fenced code", "wrap these words please\n```\nfunc main() { println(\"not wrapped at all\") }\n```", 12, 0, "wrap these\nwords please\n```\nfunc main() { println(\"not wrapped at all\") }\n```"},
+		{"disabled", "the quick brown fox", -1, 0, "the quick brown fox"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := wrapText(test.text, test.width, test.offset); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResponseWrapsToWidth(t *testing.T) {
+	setupWorkspace(t)
+	config.Width = 30
+
+	code := "\tfmt.Println(\"a line of code longer than thirty columns\")"
+	agent := newTestAgent(nil, nil)
+
+	output := captureStdout(t, func() {
+		agent.responsePrompt("This answer is long enough that it has to be wrapped several times.\n```go\n" + code + "\n```")
+	})
+
+	if !strings.Contains(output, code+"\n") {
+		t.Errorf("output %q doesn't keep the code line intact", output)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line != code && utf8.RuneCountInString(line) > config.Width {
+			t.Errorf("line %q is wider than %d columns", line, config.Width)
+		}
+	}
+}